@@ -16,6 +16,7 @@ import (
 	"message-sender/config"
 	"message-sender/repository/postgres"
 	redisrepo "message-sender/repository/redis"
+	"message-sender/sender"
 	"message-sender/service"
 	"message-sender/transport/http"
 )
@@ -55,18 +56,75 @@ func Run() {
 		logger.Fatal("Failed to initialize database schema", zap.Error(err))
 	}
 
+	senders, err := sender.NewRegistryFromConfig(cfg.Sender)
+	if err != nil {
+		logger.Fatal("Failed to create message senders", zap.Error(err))
+	}
+	if cfg.Breaker.Enabled {
+		senders.UseBreakers(cfg.Breaker, logger)
+	}
+	senders.UseThrottling(cfg.Throttle, logger)
+
+	router, err := sender.NewRouter(cfg.Routing.RulesFile, senders, logger)
+	if err != nil {
+		logger.Fatal("Failed to load routing rules", zap.Error(err))
+	}
+
 	messageSvc := service.NewMessageProcessor(
 		postgresRepo,
 		redisRepo,
 		redisRepo,
+		senders,
 		logger,
 		cfg,
 	)
 
+	if err := messageSvc.ValidateLease(); err != nil {
+		logger.Fatal("Invalid lease duration", zap.Error(err))
+	}
+
+	messageSvc.UseRouter(router)
+	messageSvc.UseRecipientLimit(redisRepo)
+
 	httpServer := http.NewServer(cfg, logger, messageSvc)
 
 	var g run.Group
 
+	routingCtx, cancelRouting := context.WithCancel(context.Background())
+	g.Add(
+		func() error {
+			return router.Watch(routingCtx, cfg.Routing.ReloadInterval)
+		},
+		func(err error) {
+			cancelRouting()
+		},
+	)
+
+	if cfg.Cluster.LeaderElection {
+		elector := service.NewLeaderElector(redisRepo, logger, cfg.Cluster.InstanceID, cfg.Cluster.LeaderTTL)
+		messageSvc.UseLeaderElection(elector)
+
+		electionCtx, cancelElection := context.WithCancel(context.Background())
+		g.Add(
+			func() error {
+				return elector.Run(electionCtx)
+			},
+			func(err error) {
+				cancelElection()
+			},
+		)
+	}
+
+	processorCtx, cancelProcessor := context.WithCancel(context.Background())
+	g.Add(
+		func() error {
+			return messageSvc.Run(processorCtx)
+		},
+		func(err error) {
+			cancelProcessor()
+		},
+	)
+
 	g.Add(
 		func() error {
 			return httpServer.Start()
@@ -2,18 +2,29 @@ package config
 
 import (
 	"fmt"
+	"os"
 	"time"
 
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	Server   ServerConfig   `mapstructure:"server"`
-	Redis    RedisConfig    `mapstructure:"redis"`
-	Database DatabaseConfig `mapstructure:"database"`
-	Message  MessageConfig  `mapstructure:"message"`
-	Log      LogConfig      `mapstructure:"log"`
-	Webhook  WebhookConfig  `mapstructure:"webhook"`
+	Server    ServerConfig    `mapstructure:"server"`
+	Redis     RedisConfig     `mapstructure:"redis"`
+	Database  DatabaseConfig  `mapstructure:"database"`
+	Message   MessageConfig   `mapstructure:"message"`
+	Log       LogConfig       `mapstructure:"log"`
+	Webhook   WebhookConfig   `mapstructure:"webhook"`
+	Cluster   ClusterConfig   `mapstructure:"cluster"`
+	Sender    SenderConfig    `mapstructure:"sender"`
+	Routing   RoutingConfig   `mapstructure:"routing"`
+	Breaker   BreakerConfig   `mapstructure:"breaker"`
+	Throttle  ThrottleConfig  `mapstructure:"throttle"`
+	RateLimit RateLimitConfig `mapstructure:"rateLimit"`
+	Priority  PriorityConfig  `mapstructure:"priority"`
+	Expiry    ExpiryConfig    `mapstructure:"expiry"`
+	Import    ImportConfig    `mapstructure:"import"`
+	Export    ExportConfig    `mapstructure:"export"`
 }
 
 type ServerConfig struct {
@@ -23,14 +34,17 @@ type ServerConfig struct {
 }
 
 type RedisConfig struct {
-	Address            string        `mapstructure:"address"`
-	DB                 int           `mapstructure:"db"`
-	PoolSize           int           `mapstructure:"poolSize"`
-	PoolTimeout        time.Duration `mapstructure:"poolTimeout"`
-	MinIdleConnection  int           `mapstructure:"minIdleConnection"`
-	MessageCacheTTL    time.Duration `mapstructure:"messageCacheTTL"`
-	ServiceStatusKey   string        `mapstructure:"serviceStatusKey"`
-	SentMessagesPrefix string        `mapstructure:"sentMessagesPrefix"`
+	Address              string        `mapstructure:"address"`
+	DB                   int           `mapstructure:"db"`
+	PoolSize             int           `mapstructure:"poolSize"`
+	PoolTimeout          time.Duration `mapstructure:"poolTimeout"`
+	MinIdleConnection    int           `mapstructure:"minIdleConnection"`
+	MessageCacheTTL      time.Duration `mapstructure:"messageCacheTTL"`
+	ServiceStatusKey     string        `mapstructure:"serviceStatusKey"`
+	SentMessagesPrefix   string        `mapstructure:"sentMessagesPrefix"`
+	LeaderKey            string        `mapstructure:"leaderKey"`
+	ControlChannel       string        `mapstructure:"controlChannel"`
+	RecipientLimitPrefix string        `mapstructure:"recipientLimitPrefix"`
 }
 
 type DatabaseConfig struct {
@@ -46,6 +60,12 @@ type MessageConfig struct {
 	BatchSize       int           `mapstructure:"batchSize"`
 	ProcessInterval time.Duration `mapstructure:"processInterval"`
 	MaxContentLen   int           `mapstructure:"maxContentLen"`
+	MaxAttempts     int           `mapstructure:"maxAttempts"`
+	RetryBaseDelay  time.Duration `mapstructure:"retryBaseDelay"`
+	RetryMaxDelay   time.Duration `mapstructure:"retryMaxDelay"`
+	StartupMode     string        `mapstructure:"startupMode"`
+	DrainTimeout    time.Duration `mapstructure:"drainTimeout"`
+	Workers         int           `mapstructure:"workers"`
 }
 
 type LogConfig struct {
@@ -54,8 +74,73 @@ type LogConfig struct {
 }
 
 type WebhookConfig struct {
-	URL     string        `mapstructure:"url"`
-	Timeout time.Duration `mapstructure:"timeout"`
+	URL                string        `mapstructure:"url"`
+	Timeout            time.Duration `mapstructure:"timeout"`
+	SuccessStatusCodes []int         `mapstructure:"successStatusCodes"`
+	MessageIDPath      string        `mapstructure:"messageIdPath"`
+	ErrorPath          string        `mapstructure:"errorPath"`
+	StrictMessageID    bool          `mapstructure:"strictMessageId"`
+	FallbackMessageID  bool          `mapstructure:"fallbackMessageId"`
+	PayloadTemplate    string        `mapstructure:"payloadTemplate"`
+	Headers            []string      `mapstructure:"headers"`
+	SenderID           string        `mapstructure:"senderId"`
+}
+
+type BreakerConfig struct {
+	Enabled      bool          `mapstructure:"enabled"`
+	FailureRatio float64       `mapstructure:"failureRatio"`
+	MinRequests  int           `mapstructure:"minRequests"`
+	WindowSize   int           `mapstructure:"windowSize"`
+	CoolDown     time.Duration `mapstructure:"coolDown"`
+}
+
+type ThrottleConfig struct {
+	MaxRate        float64       `mapstructure:"maxRate"`
+	MinRate        float64       `mapstructure:"minRate"`
+	DecreaseFactor float64       `mapstructure:"decreaseFactor"`
+	IncreaseStep   float64       `mapstructure:"increaseStep"`
+	DefaultPause   time.Duration `mapstructure:"defaultPause"`
+}
+
+type RateLimitConfig struct {
+	GlobalRate      float64       `mapstructure:"globalRate"`
+	GlobalBurst     int           `mapstructure:"globalBurst"`
+	RecipientLimit  int           `mapstructure:"recipientLimit"`
+	RecipientWindow time.Duration `mapstructure:"recipientWindow"`
+}
+
+// PriorityConfig sets how many messages each priority lane may claim per
+// tick. Lanes with a zero quota share what the message batch size leaves
+// after the other lanes, and claim at least one message.
+type PriorityConfig struct {
+	HighQuota   int `mapstructure:"highQuota"`
+	NormalQuota int `mapstructure:"normalQuota"`
+	BulkQuota   int `mapstructure:"bulkQuota"`
+}
+
+// ImportConfig tunes bulk message imports.
+type ImportConfig struct {
+	BatchSize int           `mapstructure:"batchSize"` // Rows written per INSERT
+	MaxErrors int           `mapstructure:"maxErrors"` // Row errors kept per import
+	Timeout   time.Duration `mapstructure:"timeout"`   // How long one upload may take to read
+}
+
+// ExportConfig tunes message exports.
+type ExportConfig struct {
+	FetchSize int           `mapstructure:"fetchSize"` // Rows fetched from the database cursor at a time
+	Timeout   time.Duration `mapstructure:"timeout"`   // How long one download may take to write
+}
+
+type RoutingConfig struct {
+	RulesFile      string        `mapstructure:"rulesFile"`
+	ReloadInterval time.Duration `mapstructure:"reloadInterval"`
+}
+
+type ClusterConfig struct {
+	InstanceID     string        `mapstructure:"instanceId"`
+	LeaseDuration  time.Duration `mapstructure:"leaseDuration"`
+	LeaderElection bool          `mapstructure:"leaderElection"`
+	LeaderTTL      time.Duration `mapstructure:"leaderTTL"`
 }
 
 func Parse() (*Config, error) {
@@ -96,6 +181,15 @@ func Parse() (*Config, error) {
 	if err := viper.BindEnv("redis.sentMessagesPrefix", "REDIS_SENT_MESSAGES_PREFIX"); err != nil {
 		return nil, fmt.Errorf("failed to bind env var REDIS_SENT_MESSAGES_PREFIX: %w", err)
 	}
+	if err := viper.BindEnv("redis.leaderKey", "REDIS_LEADER_KEY"); err != nil {
+		return nil, fmt.Errorf("failed to bind env var REDIS_LEADER_KEY: %w", err)
+	}
+	if err := viper.BindEnv("redis.controlChannel", "REDIS_CONTROL_CHANNEL"); err != nil {
+		return nil, fmt.Errorf("failed to bind env var REDIS_CONTROL_CHANNEL: %w", err)
+	}
+	if err := viper.BindEnv("redis.recipientLimitPrefix", "REDIS_RECIPIENT_LIMIT_PREFIX"); err != nil {
+		return nil, fmt.Errorf("failed to bind env var REDIS_RECIPIENT_LIMIT_PREFIX: %w", err)
+	}
 
 	if err := viper.BindEnv("database.host", "DATABASE_HOST"); err != nil {
 		return nil, fmt.Errorf("failed to bind env var DATABASE_HOST: %w", err)
@@ -125,6 +219,24 @@ func Parse() (*Config, error) {
 	if err := viper.BindEnv("message.maxContentLen", "MESSAGE_MAX_CONTENT_LEN"); err != nil {
 		return nil, fmt.Errorf("failed to bind env var MESSAGE_MAX_CONTENT_LEN: %w", err)
 	}
+	if err := viper.BindEnv("message.maxAttempts", "MESSAGE_MAX_ATTEMPTS"); err != nil {
+		return nil, fmt.Errorf("failed to bind env var MESSAGE_MAX_ATTEMPTS: %w", err)
+	}
+	if err := viper.BindEnv("message.retryBaseDelay", "MESSAGE_RETRY_BASE_DELAY"); err != nil {
+		return nil, fmt.Errorf("failed to bind env var MESSAGE_RETRY_BASE_DELAY: %w", err)
+	}
+	if err := viper.BindEnv("message.retryMaxDelay", "MESSAGE_RETRY_MAX_DELAY"); err != nil {
+		return nil, fmt.Errorf("failed to bind env var MESSAGE_RETRY_MAX_DELAY: %w", err)
+	}
+	if err := viper.BindEnv("message.startupMode", "MESSAGE_STARTUP_MODE"); err != nil {
+		return nil, fmt.Errorf("failed to bind env var MESSAGE_STARTUP_MODE: %w", err)
+	}
+	if err := viper.BindEnv("message.drainTimeout", "MESSAGE_DRAIN_TIMEOUT"); err != nil {
+		return nil, fmt.Errorf("failed to bind env var MESSAGE_DRAIN_TIMEOUT: %w", err)
+	}
+	if err := viper.BindEnv("message.workers", "MESSAGE_WORKERS"); err != nil {
+		return nil, fmt.Errorf("failed to bind env var MESSAGE_WORKERS: %w", err)
+	}
 
 	if err := viper.BindEnv("log.level", "LOG_LEVEL"); err != nil {
 		return nil, fmt.Errorf("failed to bind env var LOG_LEVEL: %w", err)
@@ -139,11 +251,176 @@ func Parse() (*Config, error) {
 	if err := viper.BindEnv("webhook.timeout", "WEBHOOK_TIMEOUT"); err != nil {
 		return nil, fmt.Errorf("failed to bind env var WEBHOOK_TIMEOUT: %w", err)
 	}
+	if err := viper.BindEnv("webhook.successStatusCodes", "WEBHOOK_SUCCESS_STATUS_CODES"); err != nil {
+		return nil, fmt.Errorf("failed to bind env var WEBHOOK_SUCCESS_STATUS_CODES: %w", err)
+	}
+	if err := viper.BindEnv("webhook.messageIdPath", "WEBHOOK_MESSAGE_ID_PATH"); err != nil {
+		return nil, fmt.Errorf("failed to bind env var WEBHOOK_MESSAGE_ID_PATH: %w", err)
+	}
+	if err := viper.BindEnv("webhook.errorPath", "WEBHOOK_ERROR_PATH"); err != nil {
+		return nil, fmt.Errorf("failed to bind env var WEBHOOK_ERROR_PATH: %w", err)
+	}
+	if err := viper.BindEnv("webhook.strictMessageId", "WEBHOOK_STRICT_MESSAGE_ID"); err != nil {
+		return nil, fmt.Errorf("failed to bind env var WEBHOOK_STRICT_MESSAGE_ID: %w", err)
+	}
+	if err := viper.BindEnv("webhook.fallbackMessageId", "WEBHOOK_FALLBACK_MESSAGE_ID"); err != nil {
+		return nil, fmt.Errorf("failed to bind env var WEBHOOK_FALLBACK_MESSAGE_ID: %w", err)
+	}
+	if err := viper.BindEnv("webhook.payloadTemplate", "WEBHOOK_PAYLOAD_TEMPLATE"); err != nil {
+		return nil, fmt.Errorf("failed to bind env var WEBHOOK_PAYLOAD_TEMPLATE: %w", err)
+	}
+	if err := viper.BindEnv("webhook.headers", "WEBHOOK_HEADERS"); err != nil {
+		return nil, fmt.Errorf("failed to bind env var WEBHOOK_HEADERS: %w", err)
+	}
+	if err := viper.BindEnv("webhook.senderId", "WEBHOOK_SENDER_ID"); err != nil {
+		return nil, fmt.Errorf("failed to bind env var WEBHOOK_SENDER_ID: %w", err)
+	}
+
+	if err := viper.BindEnv("cluster.instanceId", "CLUSTER_INSTANCE_ID"); err != nil {
+		return nil, fmt.Errorf("failed to bind env var CLUSTER_INSTANCE_ID: %w", err)
+	}
+	if err := viper.BindEnv("cluster.leaseDuration", "CLUSTER_LEASE_DURATION"); err != nil {
+		return nil, fmt.Errorf("failed to bind env var CLUSTER_LEASE_DURATION: %w", err)
+	}
+	if err := viper.BindEnv("cluster.leaderElection", "CLUSTER_LEADER_ELECTION"); err != nil {
+		return nil, fmt.Errorf("failed to bind env var CLUSTER_LEADER_ELECTION: %w", err)
+	}
+	if err := viper.BindEnv("cluster.leaderTTL", "CLUSTER_LEADER_TTL"); err != nil {
+		return nil, fmt.Errorf("failed to bind env var CLUSTER_LEADER_TTL: %w", err)
+	}
+
+	if err := viper.BindEnv("sender.providers", "SENDER_PROVIDERS"); err != nil {
+		return nil, fmt.Errorf("failed to bind env var SENDER_PROVIDERS: %w", err)
+	}
+	if err := viper.BindEnv("sender.defaultProvider", "SENDER_DEFAULT_PROVIDER"); err != nil {
+		return nil, fmt.Errorf("failed to bind env var SENDER_DEFAULT_PROVIDER: %w", err)
+	}
+	if err := viper.BindEnv("sender.strategy", "SENDER_STRATEGY"); err != nil {
+		return nil, fmt.Errorf("failed to bind env var SENDER_STRATEGY: %w", err)
+	}
+	if err := viper.BindEnv("sender.failover", "SENDER_FAILOVER"); err != nil {
+		return nil, fmt.Errorf("failed to bind env var SENDER_FAILOVER: %w", err)
+	}
+	if err := bindProviderEnv(); err != nil {
+		return nil, err
+	}
+
+	if err := viper.BindEnv("routing.rulesFile", "ROUTING_RULES_FILE"); err != nil {
+		return nil, fmt.Errorf("failed to bind env var ROUTING_RULES_FILE: %w", err)
+	}
+	if err := viper.BindEnv("routing.reloadInterval", "ROUTING_RELOAD_INTERVAL"); err != nil {
+		return nil, fmt.Errorf("failed to bind env var ROUTING_RELOAD_INTERVAL: %w", err)
+	}
+
+	if err := viper.BindEnv("breaker.enabled", "BREAKER_ENABLED"); err != nil {
+		return nil, fmt.Errorf("failed to bind env var BREAKER_ENABLED: %w", err)
+	}
+	if err := viper.BindEnv("breaker.failureRatio", "BREAKER_FAILURE_RATIO"); err != nil {
+		return nil, fmt.Errorf("failed to bind env var BREAKER_FAILURE_RATIO: %w", err)
+	}
+	if err := viper.BindEnv("breaker.minRequests", "BREAKER_MIN_REQUESTS"); err != nil {
+		return nil, fmt.Errorf("failed to bind env var BREAKER_MIN_REQUESTS: %w", err)
+	}
+	if err := viper.BindEnv("breaker.windowSize", "BREAKER_WINDOW_SIZE"); err != nil {
+		return nil, fmt.Errorf("failed to bind env var BREAKER_WINDOW_SIZE: %w", err)
+	}
+	if err := viper.BindEnv("breaker.coolDown", "BREAKER_COOL_DOWN"); err != nil {
+		return nil, fmt.Errorf("failed to bind env var BREAKER_COOL_DOWN: %w", err)
+	}
+
+	if err := viper.BindEnv("throttle.maxRate", "THROTTLE_MAX_RATE"); err != nil {
+		return nil, fmt.Errorf("failed to bind env var THROTTLE_MAX_RATE: %w", err)
+	}
+	if err := viper.BindEnv("throttle.minRate", "THROTTLE_MIN_RATE"); err != nil {
+		return nil, fmt.Errorf("failed to bind env var THROTTLE_MIN_RATE: %w", err)
+	}
+	if err := viper.BindEnv("throttle.decreaseFactor", "THROTTLE_DECREASE_FACTOR"); err != nil {
+		return nil, fmt.Errorf("failed to bind env var THROTTLE_DECREASE_FACTOR: %w", err)
+	}
+	if err := viper.BindEnv("throttle.increaseStep", "THROTTLE_INCREASE_STEP"); err != nil {
+		return nil, fmt.Errorf("failed to bind env var THROTTLE_INCREASE_STEP: %w", err)
+	}
+	if err := viper.BindEnv("throttle.defaultPause", "THROTTLE_DEFAULT_PAUSE"); err != nil {
+		return nil, fmt.Errorf("failed to bind env var THROTTLE_DEFAULT_PAUSE: %w", err)
+	}
+
+	if err := viper.BindEnv("rateLimit.globalRate", "RATE_LIMIT_GLOBAL_RATE"); err != nil {
+		return nil, fmt.Errorf("failed to bind env var RATE_LIMIT_GLOBAL_RATE: %w", err)
+	}
+	if err := viper.BindEnv("rateLimit.globalBurst", "RATE_LIMIT_GLOBAL_BURST"); err != nil {
+		return nil, fmt.Errorf("failed to bind env var RATE_LIMIT_GLOBAL_BURST: %w", err)
+	}
+	if err := viper.BindEnv("rateLimit.recipientLimit", "RATE_LIMIT_RECIPIENT_LIMIT"); err != nil {
+		return nil, fmt.Errorf("failed to bind env var RATE_LIMIT_RECIPIENT_LIMIT: %w", err)
+	}
+	if err := viper.BindEnv("rateLimit.recipientWindow", "RATE_LIMIT_RECIPIENT_WINDOW"); err != nil {
+		return nil, fmt.Errorf("failed to bind env var RATE_LIMIT_RECIPIENT_WINDOW: %w", err)
+	}
+
+	if err := viper.BindEnv("priority.highQuota", "PRIORITY_HIGH_QUOTA"); err != nil {
+		return nil, fmt.Errorf("failed to bind env var PRIORITY_HIGH_QUOTA: %w", err)
+	}
+	if err := viper.BindEnv("priority.normalQuota", "PRIORITY_NORMAL_QUOTA"); err != nil {
+		return nil, fmt.Errorf("failed to bind env var PRIORITY_NORMAL_QUOTA: %w", err)
+	}
+	if err := viper.BindEnv("priority.bulkQuota", "PRIORITY_BULK_QUOTA"); err != nil {
+		return nil, fmt.Errorf("failed to bind env var PRIORITY_BULK_QUOTA: %w", err)
+	}
+
+	if err := viper.BindEnv("expiry.highTTL", "EXPIRY_HIGH_TTL"); err != nil {
+		return nil, fmt.Errorf("failed to bind env var EXPIRY_HIGH_TTL: %w", err)
+	}
+	if err := viper.BindEnv("expiry.normalTTL", "EXPIRY_NORMAL_TTL"); err != nil {
+		return nil, fmt.Errorf("failed to bind env var EXPIRY_NORMAL_TTL: %w", err)
+	}
+	if err := viper.BindEnv("expiry.bulkTTL", "EXPIRY_BULK_TTL"); err != nil {
+		return nil, fmt.Errorf("failed to bind env var EXPIRY_BULK_TTL: %w", err)
+	}
+	if err := viper.BindEnv("expiry.tagTTLs", "EXPIRY_TAG_TTLS"); err != nil {
+		return nil, fmt.Errorf("failed to bind env var EXPIRY_TAG_TTLS: %w", err)
+	}
+
+	if err := viper.BindEnv("import.batchSize", "IMPORT_BATCH_SIZE"); err != nil {
+		return nil, fmt.Errorf("failed to bind env var IMPORT_BATCH_SIZE: %w", err)
+	}
+	if err := viper.BindEnv("import.maxErrors", "IMPORT_MAX_ERRORS"); err != nil {
+		return nil, fmt.Errorf("failed to bind env var IMPORT_MAX_ERRORS: %w", err)
+	}
+	if err := viper.BindEnv("import.timeout", "IMPORT_TIMEOUT"); err != nil {
+		return nil, fmt.Errorf("failed to bind env var IMPORT_TIMEOUT: %w", err)
+	}
+
+	if err := viper.BindEnv("export.fetchSize", "EXPORT_FETCH_SIZE"); err != nil {
+		return nil, fmt.Errorf("failed to bind env var EXPORT_FETCH_SIZE: %w", err)
+	}
+	if err := viper.BindEnv("export.timeout", "EXPORT_TIMEOUT"); err != nil {
+		return nil, fmt.Errorf("failed to bind env var EXPORT_TIMEOUT: %w", err)
+	}
 
 	var cfg Config
 	if err := viper.Unmarshal(&cfg); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
 	}
 
+	if err := cfg.Webhook.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid webhook config: %w", err)
+	}
+
+	if err := cfg.Sender.resolve(cfg.Webhook); err != nil {
+		return nil, fmt.Errorf("invalid sender config: %w", err)
+	}
+
+	if err := cfg.Expiry.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid expiry config: %w", err)
+	}
+
+	if cfg.Cluster.InstanceID == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve instance ID: %w", err)
+		}
+		cfg.Cluster.InstanceID = hostname
+	}
+
 	return &cfg, nil
 }
@@ -0,0 +1,79 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ExpiryConfig sets the default time-to-live of messages created without an
+// explicit expiry. A tag TTL wins over the priority TTL, and the shortest one
+// wins among several matching tags. A zero TTL means the message never
+// expires.
+type ExpiryConfig struct {
+	HighTTL   time.Duration `mapstructure:"highTTL"`
+	NormalTTL time.Duration `mapstructure:"normalTTL"`
+	BulkTTL   time.Duration `mapstructure:"bulkTTL"`
+	TagTTLs   []string      `mapstructure:"tagTTLs"`
+}
+
+// Validate checks the tag TTL entries so that a typo is reported at startup.
+func (c ExpiryConfig) Validate() error {
+	for _, entry := range c.TagTTLs {
+		if _, _, err := ParseTagTTL(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ParseTagTTL splits a "tag=duration" tag TTL entry.
+func ParseTagTTL(entry string) (string, time.Duration, error) {
+	tag, value, ok := strings.Cut(entry, "=")
+	tag = strings.TrimSpace(tag)
+	if !ok || tag == "" {
+		return "", 0, fmt.Errorf("invalid tag TTL %q, expected tag=duration", entry)
+	}
+
+	ttl, err := time.ParseDuration(strings.TrimSpace(value))
+	if err != nil || ttl <= 0 {
+		return "", 0, fmt.Errorf("invalid TTL for tag %s: %q", tag, value)
+	}
+	return tag, ttl, nil
+}
+
+// TTL returns the time-to-live of a message with the given priority and tags,
+// or 0 when it does not expire.
+func (c ExpiryConfig) TTL(priority string, tags []string) time.Duration {
+	var ttl time.Duration
+	for _, entry := range c.TagTTLs {
+		tag, tagTTL, err := ParseTagTTL(entry)
+		if err != nil || !containsTag(tags, tag) {
+			continue
+		}
+		if ttl == 0 || tagTTL < ttl {
+			ttl = tagTTL
+		}
+	}
+	if ttl > 0 {
+		return ttl
+	}
+
+	switch priority {
+	case "high":
+		return c.HighTTL
+	case "bulk":
+		return c.BulkTTL
+	default:
+		return c.NormalTTL
+	}
+}
+
+func containsTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
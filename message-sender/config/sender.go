@@ -0,0 +1,151 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	ProviderTypeWebhook = "webhook"
+
+	// StrategyPriority sends through the default provider and fails over to
+	// the others in list order.
+	StrategyPriority = "priority"
+	// StrategyWeighted spreads messages over the providers by weight and
+	// fails over to the others in list order.
+	StrategyWeighted = "weighted"
+)
+
+// SenderConfig lists the delivery providers. When no providers are configured
+// the WEBHOOK_* settings are used as a single provider named "webhook".
+type SenderConfig struct {
+	Providers       []string                  `mapstructure:"providers"`
+	DefaultProvider string                    `mapstructure:"defaultProvider"`
+	Strategy        string                    `mapstructure:"strategy"`
+	Failover        bool                      `mapstructure:"failover"`
+	Provider        map[string]ProviderConfig `mapstructure:"provider"`
+}
+
+// ProviderConfig configures one named provider. Webhook providers take the
+// same settings as WebhookConfig, each with its own timeout, credentials and
+// response contract.
+type ProviderConfig struct {
+	Type          string `mapstructure:"type"`
+	Weight        int    `mapstructure:"weight"`
+	WebhookConfig `mapstructure:",squash"`
+}
+
+// providerEnvKeys maps provider settings to the suffix of their environment
+// variable, SENDER_<NAME>_<SUFFIX>.
+var providerEnvKeys = []struct {
+	key    string
+	suffix string
+}{
+	{key: "type", suffix: "TYPE"},
+	{key: "weight", suffix: "WEIGHT"},
+	{key: "url", suffix: "URL"},
+	{key: "timeout", suffix: "TIMEOUT"},
+	{key: "successStatusCodes", suffix: "SUCCESS_STATUS_CODES"},
+	{key: "messageIdPath", suffix: "MESSAGE_ID_PATH"},
+	{key: "errorPath", suffix: "ERROR_PATH"},
+	{key: "strictMessageId", suffix: "STRICT_MESSAGE_ID"},
+	{key: "fallbackMessageId", suffix: "FALLBACK_MESSAGE_ID"},
+	{key: "payloadTemplate", suffix: "PAYLOAD_TEMPLATE"},
+	{key: "headers", suffix: "HEADERS"},
+	{key: "senderId", suffix: "SENDER_ID"},
+}
+
+// bindProviderEnv binds the settings of every provider listed in
+// SENDER_PROVIDERS.
+func bindProviderEnv() error {
+	for _, name := range splitProviders(viper.GetString("sender.providers")) {
+		envPrefix := "SENDER_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+		for _, entry := range providerEnvKeys {
+			env := envPrefix + entry.suffix
+			if err := viper.BindEnv("sender.provider."+name+"."+entry.key, env); err != nil {
+				return fmt.Errorf("failed to bind env var %s: %w", env, err)
+			}
+		}
+	}
+	return nil
+}
+
+func splitProviders(value string) []string {
+	var names []string
+	for _, name := range strings.Split(value, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// resolve fills in the legacy single-webhook provider and validates every
+// provider so that a misconfiguration is reported at startup.
+func (c *SenderConfig) resolve(webhook WebhookConfig) error {
+	c.Providers = splitProviders(strings.Join(c.Providers, ","))
+	c.DefaultProvider = strings.ToLower(strings.TrimSpace(c.DefaultProvider))
+
+	switch c.Strategy {
+	case "":
+		c.Strategy = StrategyPriority
+	case StrategyPriority, StrategyWeighted:
+	default:
+		return fmt.Errorf("unsupported strategy %q", c.Strategy)
+	}
+
+	if len(c.Providers) == 0 {
+		c.Providers = []string{ProviderTypeWebhook}
+		c.Provider = map[string]ProviderConfig{
+			ProviderTypeWebhook: {Type: ProviderTypeWebhook, WebhookConfig: webhook},
+		}
+	}
+
+	if c.Provider == nil {
+		c.Provider = make(map[string]ProviderConfig)
+	}
+
+	for _, name := range c.Providers {
+		if strings.ContainsAny(name, ". ") {
+			return fmt.Errorf("invalid provider name %q", name)
+		}
+
+		provider := c.Provider[name]
+		if provider.Type == "" {
+			provider.Type = ProviderTypeWebhook
+		}
+
+		if provider.Weight < 0 {
+			return fmt.Errorf("provider %s: weight must not be negative", name)
+		}
+		if provider.Weight == 0 {
+			provider.Weight = 1
+		}
+
+		switch provider.Type {
+		case ProviderTypeWebhook:
+			if provider.URL == "" {
+				return fmt.Errorf("provider %s: url is required", name)
+			}
+			if err := provider.WebhookConfig.Validate(); err != nil {
+				return fmt.Errorf("provider %s: %w", name, err)
+			}
+		default:
+			return fmt.Errorf("provider %s: unsupported type %q", name, provider.Type)
+		}
+
+		c.Provider[name] = provider
+	}
+
+	if c.DefaultProvider == "" {
+		c.DefaultProvider = c.Providers[0]
+	}
+	if _, ok := c.Provider[c.DefaultProvider]; !ok {
+		return fmt.Errorf("default provider %s is not in the provider list", c.DefaultProvider)
+	}
+
+	return nil
+}
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	envSecretPrefix  = "env:"
+	fileSecretPrefix = "file:"
+)
+
+// Validate checks the payload template and header entries so that a
+// misconfigured webhook is reported at startup instead of on every send.
+func (c WebhookConfig) Validate() error {
+	if c.PayloadTemplate != "" && !json.Valid([]byte(c.PayloadTemplate)) {
+		return errors.New("payload template is not valid JSON")
+	}
+
+	for _, entry := range c.Headers {
+		name, source, err := ParseHeader(entry)
+		if err != nil {
+			return err
+		}
+		if _, err := ResolveSecret(source); err != nil {
+			return fmt.Errorf("header %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// ParseHeader splits a "Name=source" header entry.
+func ParseHeader(entry string) (string, string, error) {
+	name, source, ok := strings.Cut(entry, "=")
+	name = strings.TrimSpace(name)
+	if !ok || name == "" {
+		return "", "", fmt.Errorf("invalid header %q, expected Name=value", entry)
+	}
+	return name, strings.TrimSpace(source), nil
+}
+
+// ResolveSecret returns the value behind a header source. "env:NAME" reads
+// the environment variable NAME, "file:/path" reads the file at /path and any
+// other value is used as is.
+func ResolveSecret(source string) (string, error) {
+	switch {
+	case strings.HasPrefix(source, envSecretPrefix):
+		name := strings.TrimPrefix(source, envSecretPrefix)
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set", name)
+		}
+		return value, nil
+	case strings.HasPrefix(source, fileSecretPrefix):
+		path := strings.TrimPrefix(source, fileSecretPrefix)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read secret file: %w", err)
+		}
+		return strings.TrimSpace(string(data)), nil
+	default:
+		return source, nil
+	}
+}
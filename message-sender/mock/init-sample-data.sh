@@ -31,27 +31,32 @@ PGPASSWORD=$DB_PASSWORD psql -h "$POSTGRES_HOST" -U "$POSTGRES_USER" -d "$POSTGR
 CREATE TABLE IF NOT EXISTS messages (
     id SERIAL PRIMARY KEY,
     content VARCHAR(160) NOT NULL,
-    recipient VARCHAR(15) NOT NULL,
-    is_sent BOOLEAN DEFAULT FALSE,
+    recipient VARCHAR(16) NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'queued',
+    status_updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
     sent_at TIMESTAMP,
     message_id VARCHAR(36)
 );
 
+-- Tables created before the status column existed are migrated by the service
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS status VARCHAR(16) NOT NULL DEFAULT 'queued';
+
 -- Ensure the extension is available
 CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
 
 -- Insert sample messages
-INSERT INTO messages (content, recipient, is_sent, sent_at, message_id)
+INSERT INTO messages (content, recipient, status, sent_at, message_id)
 VALUES 
-    ('Message 1', '+905500000000', false, NULL, NULL),
-    ('Message 2', '+905500000001', false, NULL, NULL),
-    ('Message 3', '+905500000002', false, NULL, NULL);
+    ('Message 1', '+905500000000', 'queued', NULL, NULL),
+    ('Message 2', '+905500000001', 'queued', NULL, NULL),
+    ('Message 3', '+905500000002', 'queued', NULL, NULL);
 
 -- Insert sample messages with different delivery times
-INSERT INTO messages (content, recipient, is_sent, sent_at, message_id)
+INSERT INTO messages (content, recipient, status, sent_at, message_id)
 VALUES 
-    ('Message 1', '+905500000000', true, NOW() - INTERVAL '2 days', 'msg-id-1'),
-    ('Message 2', '+905500000000', true, NOW() - INTERVAL '1 day', 'msg-id-2');
+    ('Message 1', '+905500000000', 'sent', NOW() - INTERVAL '2 days', 'msg-id-1'),
+    ('Message 2', '+905500000000', 'sent', NOW() - INTERVAL '1 day', 'msg-id-2');
 EOF
 
 echo "Sample data initialization completed!" 
\ No newline at end of file
@@ -0,0 +1,20 @@
+package model
+
+// ExportFormat is the encoding of a message export.
+type ExportFormat string
+
+const (
+	// ExportCSV has a header row followed by one row per message.
+	ExportCSV ExportFormat = "csv"
+
+	// ExportNDJSON has one Message JSON object per line.
+	ExportNDJSON ExportFormat = "ndjson"
+)
+
+func (f ExportFormat) IsValid() bool {
+	switch f {
+	case ExportCSV, ExportNDJSON:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,63 @@
+package model
+
+import "time"
+
+// ImportFormat is the encoding of a bulk message import.
+type ImportFormat string
+
+const (
+	// ImportCSV has a header row naming the columns of every message.
+	ImportCSV ImportFormat = "csv"
+
+	// ImportNDJSON has one CreateMessageRequest JSON object per line.
+	ImportNDJSON ImportFormat = "ndjson"
+)
+
+func (f ImportFormat) IsValid() bool {
+	switch f {
+	case ImportCSV, ImportNDJSON:
+		return true
+	}
+	return false
+}
+
+type ImportStatus string
+
+const (
+	// ImportRunning is still reading rows.
+	ImportRunning ImportStatus = "running"
+
+	// ImportCompleted read every row. Rows that failed validation are listed
+	// in its errors.
+	ImportCompleted ImportStatus = "completed"
+
+	// ImportFailed stopped early, for example because the upload was cut off.
+	// Rows imported before that are kept.
+	ImportFailed ImportStatus = "failed"
+)
+
+// ImportJob tracks one bulk import of messages.
+type ImportJob struct {
+	ID           uint         `json:"id"`
+	Format       ImportFormat `json:"format" example:"csv"`
+	Status       ImportStatus `json:"status" example:"completed"`
+	TotalRows    int          `json:"totalRows"`
+	ImportedRows int          `json:"importedRows"`
+	FailedRows   int          `json:"failedRows"`
+	Error        string       `json:"error,omitempty"` // Why a failed import stopped
+	CreatedAt    time.Time    `json:"createdAt"`
+	FinishedAt   time.Time    `json:"finishedAt,omitempty"`
+}
+
+// ImportRowError describes why a row of an import was rejected. A row with
+// several invalid fields has one error per field.
+type ImportRowError struct {
+	Row     int    `json:"row" example:"3"` // Data row number, starting at 1 after any header
+	Field   string `json:"field,omitempty" example:"recipient"`
+	Message string `json:"message" example:"must be an E.164 phone number such as +905551111111"`
+}
+
+type ImportErrorsResponse struct {
+	Errors []ImportRowError `json:"errors"`
+	Count  int              `json:"count"`
+}
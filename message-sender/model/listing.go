@@ -0,0 +1,29 @@
+package model
+
+import "time"
+
+// MessageFilter narrows down a message listing. Zero fields match every
+// message, and the time ranges include their start but not their end.
+type MessageFilter struct {
+	Statuses    []MessageStatus
+	Recipient   string
+	SentFrom    time.Time
+	SentTo      time.Time
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+	Tags        []string // Messages must have every one of these tags
+}
+
+// PageCursor is a position in a message listing, which is ordered by ID from
+// the newest message to the oldest. The API hands it out as an opaque string.
+type PageCursor struct {
+	ID       uint `json:"id"`
+	Backward bool `json:"backward,omitempty"` // The page before ID instead of the one after it
+}
+
+type MessageListResponse struct {
+	Messages   []Message `json:"messages"`
+	NextCursor string    `json:"nextCursor,omitempty"` // Older messages, empty on the last page
+	PrevCursor string    `json:"prevCursor,omitempty"` // Newer messages, empty on the first page
+	Count      *int      `json:"count,omitempty"`      // Messages matching the filters, only when requested
+}
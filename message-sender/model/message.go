@@ -1,14 +1,55 @@
 package model
 
-import "time"
+import (
+	"regexp"
+	"time"
+)
+
+// recipientPattern accepts E.164 numbers that fit the recipient column.
+var recipientPattern = regexp.MustCompile(`^\+[1-9][0-9]{6,14}$`)
 
 type Message struct {
-	ID        uint      `json:"id"`
-	Content   string    `json:"content"`
-	Recipient string    `json:"recipient"`
-	IsSent    bool      `json:"isSent"`
-	SentAt    time.Time `json:"sentAt,omitempty"`
-	MessageID string    `json:"messageId,omitempty"` // Comes from Webhook Response
+	ID              uint              `json:"id"`
+	Content         string            `json:"content"`
+	Recipient       string            `json:"recipient"`
+	Status          MessageStatus     `json:"status"`
+	Priority        MessagePriority   `json:"priority"`
+	StatusUpdatedAt time.Time         `json:"statusUpdatedAt"`
+	CreatedAt       time.Time         `json:"createdAt"`
+	SentAt          time.Time         `json:"sentAt,omitempty"`
+	MessageID       string            `json:"messageId,omitempty"` // Comes from Webhook Response
+	Provider        string            `json:"provider,omitempty"`
+	FailedOverFrom  []string          `json:"failedOverFrom,omitempty"`
+	SenderID        string            `json:"senderId,omitempty"`
+	Tags            []string          `json:"tags,omitempty"`
+	Metadata        map[string]string `json:"metadata,omitempty"`
+	SendAt          time.Time         `json:"sendAt,omitempty"`    // Not sent before this time when set
+	ExpiresAt       time.Time         `json:"expiresAt,omitempty"` // Expires instead of being sent after this time when set
+	Attempts        int               `json:"attempts"`
+	NextAttemptAt   time.Time         `json:"nextAttemptAt,omitempty"`
+	LastError       string            `json:"lastError,omitempty"`
+	ClaimedBy       string            `json:"claimedBy,omitempty"`
+	LeaseExpiresAt  time.Time         `json:"leaseExpiresAt,omitempty"`
+}
+
+// StatusHistoryEntry is one recorded status transition of a message.
+type StatusHistoryEntry struct {
+	FromStatus MessageStatus `json:"fromStatus,omitempty"` // Empty when the message was created
+	ToStatus   MessageStatus `json:"toStatus"`
+	Reason     string        `json:"reason,omitempty"`
+	ChangedAt  time.Time     `json:"changedAt"`
+}
+
+// MessageDetail is a message with every status change it went through, oldest
+// first.
+type MessageDetail struct {
+	Message
+	History []StatusHistoryEntry `json:"history"`
+}
+
+// IsValidRecipient reports whether recipient is an E.164 phone number.
+func IsValidRecipient(recipient string) bool {
+	return recipientPattern.MatchString(recipient)
 }
 
 type ActionType string
@@ -27,6 +68,44 @@ func (a ActionType) IsValid() bool {
 	return false
 }
 
+// CreateMessageRequest enqueues a message. A message with SendAt in the future
+// is held back until then.
+type CreateMessageRequest struct {
+	Content   string            `json:"content"`
+	Recipient string            `json:"recipient" example:"+905551111111"`
+	SendAt    time.Time         `json:"sendAt,omitempty"`
+	ExpiresAt time.Time         `json:"expiresAt,omitempty"`                 // Defaults to the TTL of the priority or tags
+	Priority  MessagePriority   `json:"priority,omitempty" example:"normal"` // Defaults to normal
+	Tags      []string          `json:"tags,omitempty"`
+	Metadata  map[string]string `json:"metadata,omitempty"` // Stored with the message, not sent to the provider
+}
+
+// UpdateMessageRequest changes a queued or failed message. Fields left out are
+// kept.
+type UpdateMessageRequest struct {
+	Content   *string    `json:"content,omitempty"`
+	Recipient *string    `json:"recipient,omitempty" example:"+905551111111"`
+	SendAt    *time.Time `json:"sendAt,omitempty"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+// IsEmpty reports whether the request changes nothing.
+func (r UpdateMessageRequest) IsEmpty() bool {
+	return r.Content == nil && r.Recipient == nil && r.SendAt == nil && r.ExpiresAt == nil
+}
+
+// FieldError describes why one field of a request was rejected.
+type FieldError struct {
+	Field   string `json:"field" example:"recipient"`
+	Message string `json:"message" example:"must be an E.164 phone number such as +905551111111"`
+}
+
+// ValidationErrorResponse lists every invalid field of a rejected request.
+type ValidationErrorResponse struct {
+	Error  string       `json:"error" example:"Invalid message"`
+	Fields []FieldError `json:"fields"`
+}
+
 type StartStopRequest struct {
 	Action ActionType `json:"action" validate:"oneof=start stop"`
 }
@@ -41,6 +120,36 @@ type SentMessagesResponse struct {
 	Count    int       `json:"count"`
 }
 
+type DeadMessagesResponse struct {
+	Messages []Message `json:"messages"`
+	Count    int       `json:"count"`
+}
+
+// RequeueRequest selects dead messages to put back in the queue. Either IDs
+// lists them or All is set to requeue every dead message.
+type RequeueRequest struct {
+	IDs []uint `json:"ids,omitempty"`
+	All bool   `json:"all,omitempty"`
+}
+
+type RequeueResponse struct {
+	Requeued int64 `json:"requeued"`
+}
+
+// StartupMode selects the state the processor is in when the process boots.
+type StartupMode string
+
+const (
+	// StartupStart starts sending on boot, for the whole cluster.
+	StartupStart StartupMode = "start"
+
+	// StartupRestore follows the cluster-wide status stored in Redis.
+	StartupRestore StartupMode = "restore"
+
+	// StartupStopped stays idle until a start command is broadcast.
+	StartupStopped StartupMode = "stopped"
+)
+
 type ServiceStatus string
 
 const (
@@ -48,3 +157,21 @@ const (
 
 	StatusStopped ServiceStatus = "stopped"
 )
+
+// LeaderInfo describes this instance's role when leader election is enabled.
+type LeaderInfo struct {
+	Enabled    bool   `json:"enabled"`
+	InstanceID string `json:"instanceId"`
+	IsLeader   bool   `json:"isLeader"`
+}
+
+// ServiceStatusResponse describes the cluster-wide status and this instance's
+// view of it.
+type ServiceStatusResponse struct {
+	Status    ServiceStatus  `json:"status" example:"running"`
+	Running   bool           `json:"running"`
+	Leader    LeaderInfo     `json:"leader"`
+	Breakers  []BreakerInfo  `json:"breakers"`
+	Throttles []ThrottleInfo `json:"throttles"`
+	Lanes     []LaneBacklog  `json:"lanes"`
+}
@@ -0,0 +1,39 @@
+package model
+
+import "time"
+
+// MessagePriority is the lane a message is claimed from. Every lane has its
+// own quota per tick, so lower lanes keep moving under a high lane backlog.
+type MessagePriority string
+
+const (
+	// PriorityHigh is for one-time passwords and other urgent traffic.
+	PriorityHigh MessagePriority = "high"
+
+	// PriorityNormal is for transactional traffic and the default lane.
+	PriorityNormal MessagePriority = "normal"
+
+	// PriorityBulk is for marketing and other traffic that can wait.
+	PriorityBulk MessagePriority = "bulk"
+)
+
+// Priorities lists the lanes from the highest to the lowest.
+var Priorities = []MessagePriority{PriorityHigh, PriorityNormal, PriorityBulk}
+
+func (p MessagePriority) IsValid() bool {
+	switch p {
+	case PriorityHigh, PriorityNormal, PriorityBulk:
+		return true
+	}
+	return false
+}
+
+// LaneBacklog counts the messages waiting in one priority lane.
+type LaneBacklog struct {
+	Priority MessagePriority `json:"priority" example:"high"`
+	Queued   int             `json:"queued"`             // Queued or failed messages waiting for an attempt
+	Due      int             `json:"due"`                // Messages among them that can be claimed now
+	Quota    int             `json:"quota"`              // Messages claimed from the lane per tick
+	OldestAt time.Time       `json:"oldestAt,omitempty"` // Creation time of the oldest waiting message
+	Expired  int             `json:"expired"`            // Messages that expired before they were sent
+}
@@ -0,0 +1,33 @@
+package model
+
+import "time"
+
+type BreakerState string
+
+const (
+	// BreakerClosed lets every request through.
+	BreakerClosed BreakerState = "closed"
+	// BreakerOpen rejects requests until the cool-down has passed.
+	BreakerOpen BreakerState = "open"
+	// BreakerHalfOpen lets a single probe through to decide whether to close.
+	BreakerHalfOpen BreakerState = "half-open"
+)
+
+// BreakerInfo describes the circuit breaker of one provider.
+type BreakerInfo struct {
+	Provider     string       `json:"provider" example:"primary"`
+	State        BreakerState `json:"state" example:"closed"`
+	Requests     int          `json:"requests"`
+	Failures     int          `json:"failures"`
+	FailureRatio float64      `json:"failureRatio"`
+	OpenedAt     time.Time    `json:"openedAt,omitempty"`
+	RetryAt      time.Time    `json:"retryAt,omitempty"`
+}
+
+// ThrottleInfo describes the adaptive send rate of one provider.
+type ThrottleInfo struct {
+	Provider    string    `json:"provider" example:"primary"`
+	Throttled   bool      `json:"throttled"`
+	Rate        float64   `json:"rate"` // Messages per second while throttled
+	PausedUntil time.Time `json:"pausedUntil,omitempty"`
+}
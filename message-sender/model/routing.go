@@ -0,0 +1,21 @@
+package model
+
+// RoutingRule sends messages whose recipient starts with Prefix, and that
+// carry at least one of Tags when any are set, through Provider.
+type RoutingRule struct {
+	Prefix   string   `json:"prefix" example:"+90"`
+	Tags     []string `json:"tags,omitempty" example:"otp"`
+	Provider string   `json:"provider" example:"primary"`
+	SenderID string   `json:"senderId,omitempty" example:"INSIDER"`
+}
+
+// RouteResolution explains which routing rule applies to a recipient.
+type RouteResolution struct {
+	Recipient string       `json:"recipient" example:"+905551111111"`
+	Tags      []string     `json:"tags,omitempty"`
+	Matched   bool         `json:"matched"`
+	Rule      *RoutingRule `json:"rule,omitempty"`
+	Provider  string       `json:"provider" example:"primary"`
+	SenderID  string       `json:"senderId,omitempty" example:"INSIDER"`
+	Reason    string       `json:"reason" example:"longest matching prefix +90"`
+}
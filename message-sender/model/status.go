@@ -0,0 +1,89 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+type MessageStatus string
+
+const (
+	// MessageQueued is waiting to be picked up by the processor.
+	MessageQueued MessageStatus = "queued"
+
+	// MessageProcessing has been picked up and is being sent.
+	MessageProcessing MessageStatus = "processing"
+
+	// MessageSent has been accepted by the provider.
+	MessageSent MessageStatus = "sent"
+
+	// MessageDelivered has been confirmed as delivered to the recipient.
+	MessageDelivered MessageStatus = "delivered"
+
+	// MessageFailed had its last attempt fail and is waiting for a retry.
+	MessageFailed MessageStatus = "failed"
+
+	// MessageDead ran out of attempts and is only retried when requeued.
+	MessageDead MessageStatus = "dead"
+
+	// MessageExpired was not sent before it stopped being useful.
+	MessageExpired MessageStatus = "expired"
+
+	// MessageCancelled was withdrawn before it was sent.
+	MessageCancelled MessageStatus = "cancelled"
+)
+
+var ErrInvalidTransition = errors.New("invalid message status transition")
+
+var messageTransitions = map[MessageStatus][]MessageStatus{
+	MessageQueued:     {MessageProcessing, MessageExpired, MessageCancelled},
+	MessageProcessing: {MessageSent, MessageFailed, MessageDead, MessageQueued, MessageExpired},
+	MessageSent:       {MessageDelivered},
+	MessageFailed:     {MessageProcessing, MessageExpired, MessageCancelled},
+	MessageDead:       {MessageQueued},
+}
+
+func (s MessageStatus) IsValid() bool {
+	switch s {
+	case MessageQueued, MessageProcessing, MessageSent, MessageDelivered,
+		MessageFailed, MessageDead, MessageExpired, MessageCancelled:
+		return true
+	}
+	return false
+}
+
+// IsTerminal reports whether no further transition is possible from s.
+func (s MessageStatus) IsTerminal() bool {
+	return len(messageTransitions[s]) == 0
+}
+
+func (s MessageStatus) CanTransitionTo(to MessageStatus) bool {
+	for _, next := range messageTransitions[s] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateTransition returns an error wrapping ErrInvalidTransition when a
+// message in status from is not allowed to move to status to.
+func ValidateTransition(from, to MessageStatus) error {
+	if !from.CanTransitionTo(to) {
+		return fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, from, to)
+	}
+	return nil
+}
+
+// StatusChange carries the data recorded alongside a status transition.
+type StatusChange struct {
+	At             time.Time
+	MessageID      string   // External ID returned by the provider
+	Provider       string   // Provider that handled the attempt
+	FailedOverFrom []string // Providers that were unavailable before Provider
+	SenderID       string   // Sender ID chosen by routing, if any
+	Reason         string
+	NextAttemptAt  time.Time
+	ClaimedBy      string // Worker that must still hold the claim, if any
+}
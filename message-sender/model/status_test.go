@@ -0,0 +1,51 @@
+package model
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateTransition(t *testing.T) {
+	tests := []struct {
+		from, to MessageStatus
+		valid    bool
+	}{
+		{MessageQueued, MessageProcessing, true},
+		{MessageQueued, MessageCancelled, true},
+		{MessageQueued, MessageSent, false},
+		{MessageProcessing, MessageSent, true},
+		{MessageProcessing, MessageFailed, true},
+		{MessageProcessing, MessageDead, true},
+		{MessageFailed, MessageProcessing, true},
+		{MessageSent, MessageDelivered, true},
+		{MessageSent, MessageQueued, false},
+		{MessageDelivered, MessageQueued, false},
+		{MessageDead, MessageQueued, true},
+		{MessageCancelled, MessageQueued, false},
+		{MessageExpired, MessageProcessing, false},
+	}
+
+	for _, tt := range tests {
+		err := ValidateTransition(tt.from, tt.to)
+		if tt.valid && err != nil {
+			t.Errorf("%s -> %s: expected valid transition, got %v", tt.from, tt.to, err)
+		}
+		if !tt.valid && !errors.Is(err, ErrInvalidTransition) {
+			t.Errorf("%s -> %s: expected ErrInvalidTransition, got %v", tt.from, tt.to, err)
+		}
+	}
+}
+
+func TestMessageStatus_IsTerminal(t *testing.T) {
+	for _, status := range []MessageStatus{MessageDelivered, MessageExpired, MessageCancelled} {
+		if !status.IsTerminal() {
+			t.Errorf("Expected %s to be terminal", status)
+		}
+	}
+
+	for _, status := range []MessageStatus{MessageQueued, MessageProcessing, MessageSent, MessageFailed, MessageDead} {
+		if status.IsTerminal() {
+			t.Errorf("Expected %s not to be terminal", status)
+		}
+	}
+}
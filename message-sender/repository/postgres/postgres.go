@@ -3,13 +3,17 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 
 	"message-sender/config"
 	"message-sender/model"
+	"message-sender/repository"
 )
 
 type Repository struct {
@@ -38,37 +42,82 @@ func (r *Repository) Close() error {
 	return r.db.Close()
 }
 
-func (r *Repository) GetUnsentMessages(ctx context.Context, limit int) ([]model.Message, error) {
-	query := `
-		SELECT id, content, recipient, is_sent, sent_at, message_id
-		FROM messages
-		WHERE is_sent = false
-		ORDER BY id ASC
-		LIMIT $1
-	`
+const messageColumns = `id, content, recipient, status, priority, status_updated_at, created_at, sent_at, message_id,
+	attempts, next_attempt_at, last_error, claimed_by, lease_expires_at, provider, failed_over_from, sender_id, tags, send_at, expires_at, metadata`
 
-	rows, err := r.db.QueryContext(ctx, query, limit)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query unsent messages: %w", err)
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanMessage(row rowScanner) (model.Message, error) {
+	var msg model.Message
+	var sentAt, nextAttemptAt, leaseExpiresAt, sendAt, expiresAt sql.NullTime
+	var messageID, lastError, claimedBy, provider, senderID sql.NullString
+	var metadata []byte
+
+	if err := row.Scan(
+		&msg.ID, &msg.Content, &msg.Recipient, &msg.Status, &msg.Priority, &msg.StatusUpdatedAt, &msg.CreatedAt, &sentAt, &messageID,
+		&msg.Attempts, &nextAttemptAt, &lastError, &claimedBy, &leaseExpiresAt, &provider,
+		pq.Array(&msg.FailedOverFrom), &senderID, pq.Array(&msg.Tags), &sendAt, &expiresAt, &metadata,
+	); err != nil {
+		return msg, err
 	}
-	defer rows.Close()
 
-	var messages []model.Message
-	for rows.Next() {
-		var msg model.Message
-		var sentAt sql.NullTime
-		var messageID sql.NullString
+	if sentAt.Valid {
+		msg.SentAt = sentAt.Time
+	}
 
-		if err := rows.Scan(&msg.ID, &msg.Content, &msg.Recipient, &msg.IsSent, &sentAt, &messageID); err != nil {
-			return nil, fmt.Errorf("failed to scan message row: %w", err)
-		}
+	if messageID.Valid {
+		msg.MessageID = messageID.String
+	}
+
+	if nextAttemptAt.Valid {
+		msg.NextAttemptAt = nextAttemptAt.Time
+	}
+
+	if lastError.Valid {
+		msg.LastError = lastError.String
+	}
+
+	if claimedBy.Valid {
+		msg.ClaimedBy = claimedBy.String
+	}
+
+	if leaseExpiresAt.Valid {
+		msg.LeaseExpiresAt = leaseExpiresAt.Time
+	}
 
-		if sentAt.Valid {
-			msg.SentAt = sentAt.Time
+	if provider.Valid {
+		msg.Provider = provider.String
+	}
+
+	if senderID.Valid {
+		msg.SenderID = senderID.String
+	}
+
+	if sendAt.Valid {
+		msg.SendAt = sendAt.Time
+	}
+
+	if expiresAt.Valid {
+		msg.ExpiresAt = expiresAt.Time
+	}
+
+	if len(metadata) > 0 {
+		if err := json.Unmarshal(metadata, &msg.Metadata); err != nil {
+			return msg, fmt.Errorf("failed to decode metadata: %w", err)
 		}
+	}
+
+	return msg, nil
+}
 
-		if messageID.Valid {
-			msg.MessageID = messageID.String
+func scanMessages(rows *sql.Rows) ([]model.Message, error) {
+	var messages []model.Message
+	for rows.Next() {
+		msg, err := scanMessage(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan message row: %w", err)
 		}
 
 		messages = append(messages, msg)
@@ -81,34 +130,424 @@ func (r *Repository) GetUnsentMessages(ctx context.Context, limit int) ([]model.
 	return messages, nil
 }
 
-func (r *Repository) MarkMessageAsSent(ctx context.Context, id uint, messageID string, sentAt time.Time) error {
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+// ClaimMessages atomically moves up to limit due messages of the given
+// priority to processing on behalf of workerID. Rows locked by another worker
+// are skipped, so concurrent replicas never claim the same message. The claim
+// is held for lease; once it expires ReleaseExpiredClaims puts the message
+// back in the queue. A message is not claimed while an earlier one to the same
+// recipient in the lane is still waiting for a retry or being sent, so a
+// requeued message is never overtaken.
+func (r *Repository) ClaimMessages(ctx context.Context, workerID string, priority model.MessagePriority, limit int, lease time.Duration) ([]model.Message, error) {
+	query := `
+		WITH candidates AS (
+			SELECT id AS candidate_id, status AS previous_status
+			FROM messages
+			WHERE (status = 'queued' OR status = 'failed')
+				AND (next_attempt_at IS NULL OR next_attempt_at <= NOW())
+				AND (send_at IS NULL OR send_at <= NOW())
+				AND priority = $4
+				AND NOT EXISTS (
+					SELECT 1
+					FROM messages earlier
+					WHERE earlier.recipient = messages.recipient
+						AND earlier.priority = messages.priority
+						AND earlier.id < messages.id
+						AND earlier.status IN ('queued', 'failed', 'processing')
+						AND (earlier.send_at IS NULL OR earlier.send_at <= NOW())
+				)
+			ORDER BY id ASC
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		), claimed AS (
+			UPDATE messages
+			SET status = 'processing',
+				status_updated_at = NOW(),
+				attempts = attempts + 1,
+				claimed_by = $2,
+				lease_expires_at = NOW() + make_interval(secs => $3)
+			FROM candidates
+			WHERE id = candidate_id
+			RETURNING ` + messageColumns + `
+		), history AS (
+			INSERT INTO message_status_history (message_id, from_status, to_status, reason, changed_at)
+			SELECT candidate_id, previous_status, 'processing', 'claimed by ' || $2, NOW()
+			FROM candidates
+		)
+		SELECT ` + messageColumns + `
+		FROM claimed
+		ORDER BY id ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, limit, workerID, lease.Seconds(), priority)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim messages: %w", err)
+	}
+	defer rows.Close()
+
+	return scanMessages(rows)
+}
+
+// LaneBacklog counts the queued and failed messages waiting in every priority
+// lane and the messages that expired in it. Lanes without any are left out.
+func (r *Repository) LaneBacklog(ctx context.Context) (map[model.MessagePriority]model.LaneBacklog, error) {
+	query := `
+		SELECT priority,
+			COUNT(*) FILTER (WHERE status IN ('queued', 'failed')),
+			COUNT(*) FILTER (
+				WHERE status IN ('queued', 'failed')
+					AND (next_attempt_at IS NULL OR next_attempt_at <= NOW())
+					AND (send_at IS NULL OR send_at <= NOW())
+			),
+			MIN(created_at) FILTER (WHERE status IN ('queued', 'failed')),
+			COUNT(*) FILTER (WHERE status = 'expired')
+		FROM messages
+		WHERE status IN ('queued', 'failed', 'expired')
+		GROUP BY priority
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query lane backlog: %w", err)
+	}
+	defer rows.Close()
+
+	backlog := make(map[model.MessagePriority]model.LaneBacklog)
+	for rows.Next() {
+		var lane model.LaneBacklog
+		var oldestAt sql.NullTime
+		if err := rows.Scan(&lane.Priority, &lane.Queued, &lane.Due, &oldestAt, &lane.Expired); err != nil {
+			return nil, fmt.Errorf("failed to scan lane backlog row: %w", err)
+		}
+		if oldestAt.Valid {
+			lane.OldestAt = oldestAt.Time
+		}
+		backlog[lane.Priority] = lane
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating lane backlog rows: %w", err)
+	}
+
+	return backlog, nil
+}
+
+// ExpireMessages moves queued and failed messages whose expires_at has passed
+// to the expired state, so they are never claimed.
+func (r *Repository) ExpireMessages(ctx context.Context) (int64, error) {
+	query := `
+		WITH candidates AS (
+			SELECT id AS candidate_id, status AS previous_status
+			FROM messages
+			WHERE status IN ('queued', 'failed') AND expires_at <= NOW()
+			FOR UPDATE SKIP LOCKED
+		), expired AS (
+			UPDATE messages
+			SET status = 'expired', status_updated_at = NOW(), next_attempt_at = NULL
+			FROM candidates
+			WHERE id = candidate_id
+		)
+		INSERT INTO message_status_history (message_id, from_status, to_status, reason, changed_at)
+		SELECT candidate_id, previous_status, 'expired', 'expired before it was sent', NOW()
+		FROM candidates
+	`
+
+	result, err := r.db.ExecContext(ctx, query)
+	if err != nil {
+		return 0, fmt.Errorf("failed to expire messages: %w", err)
+	}
+
+	expired, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get expired messages count: %w", err)
+	}
+
+	return expired, nil
+}
+
+// NextScheduledAt returns when the earliest queued message scheduled for the
+// future is due, or the zero time when there is none. The delay is measured
+// by the database clock, which decides when ClaimMessages picks it up.
+func (r *Repository) NextScheduledAt(ctx context.Context) (time.Time, error) {
+	query := `
+		SELECT EXTRACT(EPOCH FROM MIN(send_at) - NOW())
+		FROM messages
+		WHERE status = 'queued' AND send_at > NOW()
+	`
+
+	var delay sql.NullFloat64
+	if err := r.db.QueryRowContext(ctx, query).Scan(&delay); err != nil {
+		return time.Time{}, fmt.Errorf("failed to get next scheduled message: %w", err)
+	}
+
+	if !delay.Valid {
+		return time.Time{}, nil
+	}
+
+	return time.Now().Add(time.Duration(delay.Float64 * float64(time.Second))), nil
+}
+
+func (r *Repository) RenewClaim(ctx context.Context, id uint, workerID string, lease time.Duration) (bool, error) {
 	query := `
 		UPDATE messages
-		SET is_sent = true, message_id = $1, sent_at = $2
-		WHERE id = $3
+		SET lease_expires_at = NOW() + make_interval(secs => $3)
+		WHERE id = $1 AND status = 'processing' AND claimed_by = $2
 	`
 
-	_, err := r.db.ExecContext(ctx, query, messageID, sentAt, id)
+	result, err := r.db.ExecContext(ctx, query, id, workerID, lease.Seconds())
 	if err != nil {
-		return fmt.Errorf("failed to mark message as sent: %w", err)
+		return false, fmt.Errorf("failed to renew claim: %w", err)
+	}
+
+	renewed, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get renewed claims count: %w", err)
+	}
+
+	return renewed > 0, nil
+}
+
+// ReleaseExpiredClaims returns messages whose processing lease has expired,
+// for example because the worker holding them crashed, to the queue.
+func (r *Repository) ReleaseExpiredClaims(ctx context.Context) (int64, error) {
+	query := `
+		WITH released AS (
+			UPDATE messages
+			SET status = 'queued', status_updated_at = NOW(), claimed_by = NULL, lease_expires_at = NULL
+			WHERE status = 'processing' AND lease_expires_at < NOW()
+			RETURNING id
+		)
+		INSERT INTO message_status_history (message_id, from_status, to_status, reason, changed_at)
+		SELECT id, 'processing', 'queued', 'lease expired', NOW() FROM released
+	`
+
+	result, err := r.db.ExecContext(ctx, query)
+	if err != nil {
+		return 0, fmt.Errorf("failed to release expired claims: %w", err)
+	}
+
+	released, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get released claims count: %w", err)
+	}
+
+	return released, nil
+}
+
+// TransitionMessage moves a message to status to and records the change in
+// the status history. The current status is read under a row lock so that the
+// transition is validated against the state machine in model atomically. When
+// change.ClaimedBy is set, the message must still be processing under that
+// worker's claim, so a worker whose lease ran out cannot overwrite the outcome
+// of the one that claimed the message after it.
+func (r *Repository) TransitionMessage(ctx context.Context, id uint, to model.MessageStatus, change model.StatusChange) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	var from model.MessageStatus
+	var claimedBy sql.NullString
+	err = tx.QueryRowContext(ctx, `SELECT status, claimed_by FROM messages WHERE id = $1 FOR UPDATE`, id).Scan(&from, &claimedBy)
+	if errors.Is(err, sql.ErrNoRows) {
+		return repository.ErrMessageNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to lock message: %w", err)
+	}
+
+	if change.ClaimedBy != "" && (from != model.MessageProcessing || claimedBy.String != change.ClaimedBy) {
+		return repository.ErrClaimLost
+	}
+
+	if err := transitionMessage(ctx, tx, id, from, to, change); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit status change: %w", err)
 	}
 
 	return nil
 }
 
+// transitionMessage validates and applies a status change to a message locked
+// by tx whose current status is from, and records it in the status history.
+func transitionMessage(ctx context.Context, tx *sql.Tx, id uint, from, to model.MessageStatus, change model.StatusChange) error {
+	if change.At.IsZero() {
+		change.At = time.Now()
+	}
+
+	if err := model.ValidateTransition(from, to); err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE messages
+		SET status = $1,
+			status_updated_at = $2,
+			attempts = CASE
+				WHEN $1 = 'processing' THEN attempts + 1
+				WHEN $1 = 'queued' AND status = 'processing' THEN GREATEST(attempts - 1, 0)
+				ELSE attempts
+			END,
+			sent_at = CASE WHEN $1 = 'sent' THEN $2 ELSE sent_at END,
+			message_id = COALESCE($3, message_id),
+			last_error = COALESCE($4, last_error),
+			next_attempt_at = $5,
+			provider = COALESCE($6, provider),
+			failed_over_from = CASE WHEN $6 IS NULL THEN failed_over_from ELSE $7 END,
+			sender_id = CASE WHEN $6 IS NULL THEN sender_id ELSE $8 END,
+			claimed_by = CASE WHEN $1 = 'processing' THEN claimed_by END,
+			lease_expires_at = CASE WHEN $1 = 'processing' THEN lease_expires_at END
+		WHERE id = $9
+	`
+
+	_, err := tx.ExecContext(ctx, query,
+		to, change.At, nullString(change.MessageID), nullString(change.Reason), nullTime(change.NextAttemptAt),
+		nullString(change.Provider), pq.Array(change.FailedOverFrom), nullString(change.SenderID), id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update message status: %w", err)
+	}
+
+	historyQuery := `
+		INSERT INTO message_status_history (message_id, from_status, to_status, reason, changed_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+
+	if _, err := tx.ExecContext(ctx, historyQuery, id, from, to, nullString(change.Reason), change.At); err != nil {
+		return fmt.Errorf("failed to record status change: %w", err)
+	}
+
+	return nil
+}
+
+// lockPendingMessage reads a message under a row lock and checks that it is
+// still waiting to be sent, queued or failed and waiting for a retry.
+// Claiming skips locked rows and checks the status again, so a message locked
+// here is never sent while it is being changed.
+func lockPendingMessage(ctx context.Context, tx *sql.Tx, id uint) (model.Message, error) {
+	query := `
+		SELECT ` + messageColumns + `
+		FROM messages
+		WHERE id = $1
+		FOR UPDATE
+	`
+
+	msg, err := scanMessage(tx.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return msg, repository.ErrMessageNotFound
+	}
+	if err != nil {
+		return msg, fmt.Errorf("failed to lock message: %w", err)
+	}
+
+	// A message is pending as long as it can still be cancelled
+	if !msg.Status.CanTransitionTo(model.MessageCancelled) {
+		return msg, fmt.Errorf("%w: message is %s", repository.ErrMessageNotPending, msg.Status)
+	}
+
+	return msg, nil
+}
+
+func (r *Repository) UpdatePendingMessage(ctx context.Context, id uint, update func(*model.Message) error) (*model.Message, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	msg, err := lockPendingMessage(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := update(&msg); err != nil {
+		return nil, err
+	}
+
+	query := `
+		UPDATE messages
+		SET content = $1, recipient = $2, send_at = $3, expires_at = $4
+		WHERE id = $5
+		RETURNING ` + messageColumns
+
+	updated, err := scanMessage(tx.QueryRowContext(ctx, query,
+		msg.Content, msg.Recipient, nullTime(msg.SendAt), nullTime(msg.ExpiresAt), id,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to update message: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit message update: %w", err)
+	}
+
+	return &updated, nil
+}
+
+func (r *Repository) CancelPendingMessage(ctx context.Context, id uint, reason string) (*model.Message, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	msg, err := lockPendingMessage(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := transitionMessage(ctx, tx, id, msg.Status, model.MessageCancelled, model.StatusChange{Reason: reason}); err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT ` + messageColumns + `
+		FROM messages
+		WHERE id = $1
+	`
+
+	cancelled, err := scanMessage(tx.QueryRowContext(ctx, query, id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cancelled message: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit message cancellation: %w", err)
+	}
+
+	return &cancelled, nil
+}
+
 func (r *Repository) GetSentMessages(ctx context.Context, page, limit int) ([]model.Message, int, error) {
 	offset := (page - 1) * limit
 
 	var total int
-	countQuery := `SELECT COUNT(*) FROM messages WHERE is_sent = true`
+	countQuery := `SELECT COUNT(*) FROM messages WHERE status IN ('sent', 'delivered')`
 	if err := r.db.QueryRowContext(ctx, countQuery).Scan(&total); err != nil {
 		return nil, 0, fmt.Errorf("failed to get sent messages count: %w", err)
 	}
 
 	query := `
-		SELECT id, content, recipient, is_sent, sent_at, message_id
+		SELECT ` + messageColumns + `
 		FROM messages
-		WHERE is_sent = true
+		WHERE status IN ('sent', 'delivered')
 		ORDER BY sent_at DESC
 		LIMIT $1 OFFSET $2
 	`
@@ -119,74 +558,539 @@ func (r *Repository) GetSentMessages(ctx context.Context, page, limit int) ([]mo
 	}
 	defer rows.Close()
 
-	var messages []model.Message
-	for rows.Next() {
-		var msg model.Message
-		var sentAt sql.NullTime
-		var messageID sql.NullString
+	messages, err := scanMessages(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return messages, total, nil
+}
+
+// messageFilterClause builds the WHERE conditions for filter, numbering its
+// bind parameters after the ones already in args.
+func messageFilterClause(filter model.MessageFilter, args []interface{}) (string, []interface{}) {
+	var conditions []string
+	add := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if len(filter.Statuses) > 0 {
+		statuses := make([]string, len(filter.Statuses))
+		for i, status := range filter.Statuses {
+			statuses[i] = string(status)
+		}
+		add("status = ANY($%d)", pq.Array(statuses))
+	}
+	if filter.Recipient != "" {
+		add("recipient = $%d", filter.Recipient)
+	}
+	if !filter.SentFrom.IsZero() {
+		add("sent_at >= $%d", filter.SentFrom)
+	}
+	if !filter.SentTo.IsZero() {
+		add("sent_at < $%d", filter.SentTo)
+	}
+	if !filter.CreatedFrom.IsZero() {
+		add("created_at >= $%d", filter.CreatedFrom)
+	}
+	if !filter.CreatedTo.IsZero() {
+		add("created_at < $%d", filter.CreatedTo)
+	}
+	if len(filter.Tags) > 0 {
+		add("tags @> $%d::TEXT[]", pq.Array(filter.Tags))
+	}
+
+	if len(conditions) == 0 {
+		return "TRUE", args
+	}
+	return strings.Join(conditions, " AND "), args
+}
+
+// ListMessages pages through messages by ID instead of OFFSET, so every page
+// costs the same however deep into the listing it is.
+func (r *Repository) ListMessages(ctx context.Context, filter model.MessageFilter, cursor model.PageCursor, limit int) ([]model.Message, error) {
+	where, args := messageFilterClause(filter, nil)
+
+	order := "DESC"
+	if cursor.ID != 0 {
+		args = append(args, cursor.ID)
+		if cursor.Backward {
+			where += fmt.Sprintf(" AND id > $%d", len(args))
+			order = "ASC"
+		} else {
+			where += fmt.Sprintf(" AND id < $%d", len(args))
+		}
+	}
+	args = append(args, limit)
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM messages
+		WHERE %s
+		ORDER BY id %s
+		LIMIT $%d
+	`, messageColumns, where, order, len(args))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query messages: %w", err)
+	}
+	defer rows.Close()
+
+	messages, err := scanMessages(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	// A backward page is read oldest first
+	if order == "ASC" {
+		for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+			messages[i], messages[j] = messages[j], messages[i]
+		}
+	}
+
+	return messages, nil
+}
+
+func (r *Repository) CountMessages(ctx context.Context, filter model.MessageFilter) (int, error) {
+	where, args := messageFilterClause(filter, nil)
+
+	var total int
+	query := `SELECT COUNT(*) FROM messages WHERE ` + where
+	if err := r.db.QueryRowContext(ctx, query, args...).Scan(&total); err != nil {
+		return 0, fmt.Errorf("failed to count messages: %w", err)
+	}
+
+	return total, nil
+}
+
+// ExportMessages reads the matching messages through a server-side cursor, so
+// only fetchSize rows are held in memory however many there are.
+func (r *Repository) ExportMessages(ctx context.Context, filter model.MessageFilter, fetchSize int, fn func(model.Message) error) error {
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	where, args := messageFilterClause(filter, nil)
+	declare := `
+		DECLARE message_export NO SCROLL CURSOR FOR
+		SELECT ` + messageColumns + `
+		FROM messages
+		WHERE ` + where + `
+		ORDER BY id ASC
+	`
+	if _, err := tx.ExecContext(ctx, declare, args...); err != nil {
+		return fmt.Errorf("failed to declare export cursor: %w", err)
+	}
 
-		if err := rows.Scan(&msg.ID, &msg.Content, &msg.Recipient, &msg.IsSent, &sentAt, &messageID); err != nil {
-			return nil, 0, fmt.Errorf("failed to scan message row: %w", err)
+	fetch := fmt.Sprintf(`FETCH FORWARD %d FROM message_export`, fetchSize)
+	for {
+		rows, err := tx.QueryContext(ctx, fetch)
+		if err != nil {
+			return fmt.Errorf("failed to fetch exported messages: %w", err)
 		}
 
-		if sentAt.Valid {
-			msg.SentAt = sentAt.Time
+		messages, err := scanMessages(rows)
+		rows.Close()
+		if err != nil {
+			return err
 		}
 
-		if messageID.Valid {
-			msg.MessageID = messageID.String
+		for _, msg := range messages {
+			if err := fn(msg); err != nil {
+				return err
+			}
 		}
 
-		messages = append(messages, msg)
+		if len(messages) < fetchSize {
+			break
+		}
 	}
 
-	if err := rows.Err(); err != nil {
-		return nil, 0, fmt.Errorf("error iterating message rows: %w", err)
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Repository) GetDeadMessages(ctx context.Context, page, limit int) ([]model.Message, int, error) {
+	offset := (page - 1) * limit
+
+	var total int
+	countQuery := `SELECT COUNT(*) FROM messages WHERE status = 'dead'`
+	if err := r.db.QueryRowContext(ctx, countQuery).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to get dead messages count: %w", err)
+	}
+
+	query := `
+		SELECT ` + messageColumns + `
+		FROM messages
+		WHERE status = 'dead'
+		ORDER BY status_updated_at DESC
+		LIMIT $1 OFFSET $2
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query dead messages: %w", err)
+	}
+	defer rows.Close()
+
+	messages, err := scanMessages(rows)
+	if err != nil {
+		return nil, 0, err
 	}
 
 	return messages, total, nil
 }
 
+// RequeueDeadMessages moves the given dead messages back to the queue with a
+// fresh attempt budget. An empty ids slice requeues nothing.
+func (r *Repository) RequeueDeadMessages(ctx context.Context, ids []uint) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	idList := make([]int64, len(ids))
+	for i, id := range ids {
+		idList[i] = int64(id)
+	}
+
+	return r.requeueDeadMessages(ctx, ` AND id = ANY($1)`, pq.Array(idList))
+}
+
+func (r *Repository) RequeueAllDeadMessages(ctx context.Context) (int64, error) {
+	return r.requeueDeadMessages(ctx, "")
+}
+
+func (r *Repository) requeueDeadMessages(ctx context.Context, filter string, args ...interface{}) (int64, error) {
+	query := `
+		WITH requeued AS (
+			UPDATE messages
+			SET status = 'queued', status_updated_at = NOW(), attempts = 0, next_attempt_at = NULL
+			WHERE status = 'dead'` + filter + `
+			RETURNING id
+		)
+		INSERT INTO message_status_history (message_id, from_status, to_status, reason, changed_at)
+		SELECT id, 'dead', 'queued', 'requeued', NOW() FROM requeued
+	`
+
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to requeue dead messages: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get requeued messages count: %w", err)
+	}
+
+	return affected, nil
+}
+
 func (r *Repository) GetMessageByID(ctx context.Context, id uint) (*model.Message, error) {
 	query := `
-		SELECT id, content, recipient, is_sent, sent_at, message_id
+		SELECT ` + messageColumns + `
 		FROM messages
 		WHERE id = $1
 	`
 
-	var msg model.Message
-	var sentAt sql.NullTime
-	var messageID sql.NullString
+	msg, err := scanMessage(r.db.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get message by ID: %w", err)
+	}
+
+	return &msg, nil
+}
+
+func (r *Repository) GetMessageByExternalID(ctx context.Context, messageID string) (*model.Message, error) {
+	query := `
+		SELECT ` + messageColumns + `
+		FROM messages
+		WHERE message_id = $1
+		ORDER BY id DESC
+		LIMIT 1
+	`
+
+	msg, err := scanMessage(r.db.QueryRowContext(ctx, query, messageID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get message by external ID: %w", err)
+	}
+
+	return &msg, nil
+}
+
+// GetStatusHistory returns the status changes of a message, oldest first.
+func (r *Repository) GetStatusHistory(ctx context.Context, id uint) ([]model.StatusHistoryEntry, error) {
+	query := `
+		SELECT from_status, to_status, reason, changed_at
+		FROM message_status_history
+		WHERE message_id = $1
+		ORDER BY changed_at ASC, id ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query status history: %w", err)
+	}
+	defer rows.Close()
+
+	history := []model.StatusHistoryEntry{}
+	for rows.Next() {
+		var entry model.StatusHistoryEntry
+		var fromStatus, reason sql.NullString
+		if err := rows.Scan(&fromStatus, &entry.ToStatus, &reason, &entry.ChangedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan status history row: %w", err)
+		}
+		if fromStatus.Valid {
+			entry.FromStatus = model.MessageStatus(fromStatus.String)
+		}
+		if reason.Valid {
+			entry.Reason = reason.String
+		}
+		history = append(history, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating status history rows: %w", err)
+	}
+
+	return history, nil
+}
+
+func (r *Repository) SaveMessage(ctx context.Context, message *model.Message) error {
+	if message.Status == "" {
+		message.Status = model.MessageQueued
+	}
+	if message.Priority == "" {
+		message.Priority = model.PriorityNormal
+	}
+
+	query := `
+		INSERT INTO messages (content, recipient, status, priority, tags, send_at, expires_at, metadata)
+		VALUES ($1, $2, $3, $4, COALESCE($5::TEXT[], '{}'), $6, $7, $8)
+		RETURNING id, status_updated_at, created_at
+	`
+
+	metadata, err := json.Marshal(message.Metadata)
+	if err != nil {
+		return fmt.Errorf("failed to encode metadata: %w", err)
+	}
+	if message.Metadata == nil {
+		metadata = []byte("{}")
+	}
+
+	return r.db.QueryRowContext(ctx, query,
+		message.Content, message.Recipient, message.Status, message.Priority, pq.Array(message.Tags),
+		nullTime(message.SendAt), nullTime(message.ExpiresAt), string(metadata),
+	).Scan(&message.ID, &message.StatusUpdatedAt, &message.CreatedAt)
+}
+
+// messageInsertColumns are the columns SaveMessages writes for every message.
+const messageInsertColumns = 8
+
+// SaveMessages inserts messages with a single multi-row INSERT. The caller
+// keeps batches small enough to stay under the bind parameter limit.
+func (r *Repository) SaveMessages(ctx context.Context, messages []model.Message) (int64, error) {
+	if len(messages) == 0 {
+		return 0, nil
+	}
+
+	var query strings.Builder
+	query.WriteString(`INSERT INTO messages (content, recipient, status, priority, tags, send_at, expires_at, metadata) VALUES `)
+
+	args := make([]interface{}, 0, len(messages)*messageInsertColumns)
+	for i, msg := range messages {
+		if msg.Status == "" {
+			msg.Status = model.MessageQueued
+		}
+		if msg.Priority == "" {
+			msg.Priority = model.PriorityNormal
+		}
+
+		metadata, err := json.Marshal(msg.Metadata)
+		if err != nil {
+			return 0, fmt.Errorf("failed to encode metadata: %w", err)
+		}
+		if msg.Metadata == nil {
+			metadata = []byte("{}")
+		}
+
+		if i > 0 {
+			query.WriteString(", ")
+		}
+		n := i * messageInsertColumns
+		fmt.Fprintf(&query, "($%d, $%d, $%d, $%d, COALESCE($%d::TEXT[], '{}'), $%d, $%d, $%d)",
+			n+1, n+2, n+3, n+4, n+5, n+6, n+7, n+8)
+
+		args = append(args,
+			msg.Content, msg.Recipient, msg.Status, msg.Priority, pq.Array(msg.Tags),
+			nullTime(msg.SendAt), nullTime(msg.ExpiresAt), string(metadata),
+		)
+	}
+
+	result, err := r.db.ExecContext(ctx, query.String(), args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert messages: %w", err)
+	}
+
+	inserted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get inserted messages count: %w", err)
+	}
+
+	return inserted, nil
+}
+
+func (r *Repository) CreateImportJob(ctx context.Context, job *model.ImportJob) error {
+	if job.Status == "" {
+		job.Status = model.ImportRunning
+	}
+
+	query := `
+		INSERT INTO message_imports (format, status)
+		VALUES ($1, $2)
+		RETURNING id, created_at
+	`
+
+	if err := r.db.QueryRowContext(ctx, query, job.Format, job.Status).Scan(&job.ID, &job.CreatedAt); err != nil {
+		return fmt.Errorf("failed to create import job: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateImportJob stores the status and row counts of job.
+func (r *Repository) UpdateImportJob(ctx context.Context, job *model.ImportJob) error {
+	query := `
+		UPDATE message_imports
+		SET status = $1, total_rows = $2, imported_rows = $3, failed_rows = $4, error = $5, finished_at = $6
+		WHERE id = $7
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		job.Status, job.TotalRows, job.ImportedRows, job.FailedRows, nullString(job.Error), nullTime(job.FinishedAt), job.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update import job: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Repository) GetImportJob(ctx context.Context, id uint) (*model.ImportJob, error) {
+	query := `
+		SELECT id, format, status, total_rows, imported_rows, failed_rows, error, created_at, finished_at
+		FROM message_imports
+		WHERE id = $1
+	`
+
+	var job model.ImportJob
+	var jobError sql.NullString
+	var finishedAt sql.NullTime
 
 	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&msg.ID, &msg.Content, &msg.Recipient, &msg.IsSent, &sentAt, &messageID,
+		&job.ID, &job.Format, &job.Status, &job.TotalRows, &job.ImportedRows, &job.FailedRows,
+		&jobError, &job.CreatedAt, &finishedAt,
 	)
-	if err == sql.ErrNoRows {
+	if errors.Is(err, sql.ErrNoRows) {
 		return nil, nil
 	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to get message by ID: %w", err)
+		return nil, fmt.Errorf("failed to get import job: %w", err)
 	}
 
-	if sentAt.Valid {
-		msg.SentAt = sentAt.Time
+	if jobError.Valid {
+		job.Error = jobError.String
 	}
 
-	if messageID.Valid {
-		msg.MessageID = messageID.String
+	if finishedAt.Valid {
+		job.FinishedAt = finishedAt.Time
 	}
 
-	return &msg, nil
+	return &job, nil
 }
 
-func (r *Repository) SaveMessage(ctx context.Context, message *model.Message) error {
+// SaveImportErrors records the row errors of an import job with a single
+// multi-row INSERT.
+func (r *Repository) SaveImportErrors(ctx context.Context, jobID uint, rowErrors []model.ImportRowError) error {
+	if len(rowErrors) == 0 {
+		return nil
+	}
+
+	var query strings.Builder
+	query.WriteString(`INSERT INTO message_import_errors (import_id, row_number, field, message) VALUES `)
+
+	args := make([]interface{}, 0, len(rowErrors)*4)
+	for i, rowError := range rowErrors {
+		if i > 0 {
+			query.WriteString(", ")
+		}
+		n := i * 4
+		fmt.Fprintf(&query, "($%d, $%d, $%d, $%d)", n+1, n+2, n+3, n+4)
+		args = append(args, jobID, rowError.Row, nullString(rowError.Field), rowError.Message)
+	}
+
+	if _, err := r.db.ExecContext(ctx, query.String(), args...); err != nil {
+		return fmt.Errorf("failed to save import errors: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Repository) GetImportErrors(ctx context.Context, jobID uint, page, limit int) ([]model.ImportRowError, int, error) {
+	offset := (page - 1) * limit
+
+	var total int
+	countQuery := `SELECT COUNT(*) FROM message_import_errors WHERE import_id = $1`
+	if err := r.db.QueryRowContext(ctx, countQuery, jobID).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to get import errors count: %w", err)
+	}
+
 	query := `
-		INSERT INTO messages (content, recipient, is_sent)
-		VALUES ($1, $2, $3)
-		RETURNING id
+		SELECT row_number, field, message
+		FROM message_import_errors
+		WHERE import_id = $1
+		ORDER BY row_number ASC, id ASC
+		LIMIT $2 OFFSET $3
 	`
 
-	return r.db.QueryRowContext(ctx, query, message.Content, message.Recipient, message.IsSent).Scan(&message.ID)
+	rows, err := r.db.QueryContext(ctx, query, jobID, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query import errors: %w", err)
+	}
+	defer rows.Close()
+
+	rowErrors := []model.ImportRowError{}
+	for rows.Next() {
+		var rowError model.ImportRowError
+		var field sql.NullString
+		if err := rows.Scan(&rowError.Row, &field, &rowError.Message); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan import error row: %w", err)
+		}
+		if field.Valid {
+			rowError.Field = field.String
+		}
+		rowErrors = append(rowErrors, rowError)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating import error rows: %w", err)
+	}
+
+	return rowErrors, total, nil
 }
 
 func (r *Repository) InitSchema(ctx context.Context) error {
@@ -194,11 +1098,105 @@ func (r *Repository) InitSchema(ctx context.Context) error {
 		CREATE TABLE IF NOT EXISTS messages (
 			id SERIAL PRIMARY KEY,
 			content VARCHAR(160) NOT NULL,
-			recipient VARCHAR(15) NOT NULL,
-			is_sent BOOLEAN DEFAULT FALSE,
+			recipient VARCHAR(16) NOT NULL,
+			status VARCHAR(16) NOT NULL DEFAULT 'queued',
+			status_updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+			created_at TIMESTAMP NOT NULL DEFAULT NOW(),
 			sent_at TIMESTAMP,
 			message_id VARCHAR(36)
-		)
+		);
+
+		ALTER TABLE messages ADD COLUMN IF NOT EXISTS status VARCHAR(16) NOT NULL DEFAULT 'queued';
+		ALTER TABLE messages ADD COLUMN IF NOT EXISTS status_updated_at TIMESTAMP NOT NULL DEFAULT NOW();
+		ALTER TABLE messages ADD COLUMN IF NOT EXISTS created_at TIMESTAMP NOT NULL DEFAULT NOW();
+		ALTER TABLE messages ADD COLUMN IF NOT EXISTS attempts INTEGER NOT NULL DEFAULT 0;
+		ALTER TABLE messages ADD COLUMN IF NOT EXISTS next_attempt_at TIMESTAMP;
+		ALTER TABLE messages ADD COLUMN IF NOT EXISTS last_error TEXT;
+		ALTER TABLE messages ADD COLUMN IF NOT EXISTS claimed_by VARCHAR(255);
+		ALTER TABLE messages ALTER COLUMN message_id TYPE VARCHAR(255);
+		ALTER TABLE messages ALTER COLUMN recipient TYPE VARCHAR(16);
+		ALTER TABLE messages ADD COLUMN IF NOT EXISTS lease_expires_at TIMESTAMP;
+		ALTER TABLE messages ADD COLUMN IF NOT EXISTS provider VARCHAR(64);
+		ALTER TABLE messages ADD COLUMN IF NOT EXISTS failed_over_from TEXT[];
+		ALTER TABLE messages ADD COLUMN IF NOT EXISTS sender_id VARCHAR(64);
+		ALTER TABLE messages ADD COLUMN IF NOT EXISTS tags TEXT[] NOT NULL DEFAULT '{}';
+		ALTER TABLE messages ADD COLUMN IF NOT EXISTS send_at TIMESTAMP;
+		ALTER TABLE messages ADD COLUMN IF NOT EXISTS priority VARCHAR(8) NOT NULL DEFAULT 'normal';
+		ALTER TABLE messages ADD COLUMN IF NOT EXISTS expires_at TIMESTAMP;
+		ALTER TABLE messages ADD COLUMN IF NOT EXISTS metadata JSONB NOT NULL DEFAULT '{}';
+
+		-- Fold the legacy is_sent and dead_at columns into status.
+		DO $$
+		BEGIN
+			IF EXISTS (
+				SELECT 1 FROM information_schema.columns
+				WHERE table_name = 'messages' AND column_name = 'is_sent'
+			) THEN
+				UPDATE messages SET status = 'sent', status_updated_at = COALESCE(sent_at, NOW())
+				WHERE is_sent = true;
+				ALTER TABLE messages DROP COLUMN is_sent;
+			END IF;
+
+			IF EXISTS (
+				SELECT 1 FROM information_schema.columns
+				WHERE table_name = 'messages' AND column_name = 'dead_at'
+			) THEN
+				UPDATE messages SET status = 'dead', status_updated_at = dead_at
+				WHERE dead_at IS NOT NULL;
+				ALTER TABLE messages DROP COLUMN dead_at;
+			END IF;
+		END $$;
+
+		CREATE INDEX IF NOT EXISTS idx_messages_status ON messages (status);
+		CREATE INDEX IF NOT EXISTS idx_messages_lease_expires_at ON messages (lease_expires_at)
+			WHERE status = 'processing';
+		CREATE INDEX IF NOT EXISTS idx_messages_priority_queue ON messages (priority, id)
+			WHERE status IN ('queued', 'failed');
+		CREATE INDEX IF NOT EXISTS idx_messages_send_at ON messages (send_at)
+			WHERE status = 'queued' AND send_at IS NOT NULL;
+		CREATE INDEX IF NOT EXISTS idx_messages_expires_at ON messages (expires_at)
+			WHERE status IN ('queued', 'failed') AND expires_at IS NOT NULL;
+		CREATE INDEX IF NOT EXISTS idx_messages_message_id ON messages (message_id)
+			WHERE message_id IS NOT NULL;
+		CREATE INDEX IF NOT EXISTS idx_messages_status_id ON messages (status, id);
+		CREATE INDEX IF NOT EXISTS idx_messages_recipient_id ON messages (recipient, id);
+		CREATE INDEX IF NOT EXISTS idx_messages_created_at ON messages (created_at);
+		CREATE INDEX IF NOT EXISTS idx_messages_sent_at ON messages (sent_at)
+			WHERE sent_at IS NOT NULL;
+		CREATE INDEX IF NOT EXISTS idx_messages_tags ON messages USING GIN (tags);
+
+		CREATE TABLE IF NOT EXISTS message_status_history (
+			id BIGSERIAL PRIMARY KEY,
+			message_id INTEGER NOT NULL REFERENCES messages (id) ON DELETE CASCADE,
+			from_status VARCHAR(16),
+			to_status VARCHAR(16) NOT NULL,
+			reason TEXT,
+			changed_at TIMESTAMP NOT NULL DEFAULT NOW()
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_message_status_history_message_id ON message_status_history (message_id);
+
+		CREATE TABLE IF NOT EXISTS message_imports (
+			id SERIAL PRIMARY KEY,
+			format VARCHAR(8) NOT NULL,
+			status VARCHAR(16) NOT NULL DEFAULT 'running',
+			total_rows INTEGER NOT NULL DEFAULT 0,
+			imported_rows INTEGER NOT NULL DEFAULT 0,
+			failed_rows INTEGER NOT NULL DEFAULT 0,
+			error TEXT,
+			created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+			finished_at TIMESTAMP
+		);
+
+		CREATE TABLE IF NOT EXISTS message_import_errors (
+			id BIGSERIAL PRIMARY KEY,
+			import_id INTEGER NOT NULL REFERENCES message_imports (id) ON DELETE CASCADE,
+			row_number INTEGER NOT NULL,
+			field VARCHAR(64),
+			message TEXT NOT NULL
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_message_import_errors_import_id ON message_import_errors (import_id, row_number);
 	`
 
 	_, err := r.db.ExecContext(ctx, schema)
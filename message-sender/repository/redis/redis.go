@@ -12,19 +12,62 @@ import (
 	"message-sender/model"
 )
 
+// acquireLeadershipScript takes the leader key when it is free and extends it
+// when it is already held by the caller.
+var acquireLeadershipScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+if redis.call("SET", KEYS[1], ARGV[1], "NX", "PX", ARGV[2]) then
+	return 1
+end
+return 0
+`)
+
+// releaseLeadershipScript deletes the leader key only when it is held by the
+// caller, so a stale leader cannot release a lock taken over by another one.
+var releaseLeadershipScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// reserveRecipientSendScript keeps the sends to a recipient in a sorted set
+// scored by time. It records a send when fewer than the limit fall within the
+// window and otherwise returns the milliseconds until the oldest one leaves it.
+var reserveRecipientSendScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+redis.call("ZREMRANGEBYSCORE", KEYS[1], "-inf", now - window)
+if redis.call("ZCARD", KEYS[1]) < tonumber(ARGV[3]) then
+	redis.call("ZADD", KEYS[1], now, ARGV[4])
+	redis.call("PEXPIRE", KEYS[1], window)
+	return 0
+end
+local oldest = redis.call("ZRANGE", KEYS[1], 0, 0, "WITHSCORES")
+return tonumber(oldest[2]) + window - now
+`)
+
 type Repository struct {
-	client             *redis.Client
-	serviceStatusKey   string
-	sentMessagesPrefix string
-	messageCacheTTL    time.Duration
+	client               *redis.Client
+	serviceStatusKey     string
+	sentMessagesPrefix   string
+	messageCacheTTL      time.Duration
+	leaderKey            string
+	controlChannel       string
+	recipientLimitPrefix string
 }
 
 func NewRepository(client *redis.Client, cfg *config.RedisConfig) *Repository {
 	return &Repository{
-		client:             client,
-		serviceStatusKey:   cfg.ServiceStatusKey,
-		sentMessagesPrefix: cfg.SentMessagesPrefix,
-		messageCacheTTL:    cfg.MessageCacheTTL,
+		client:               client,
+		serviceStatusKey:     cfg.ServiceStatusKey,
+		sentMessagesPrefix:   cfg.SentMessagesPrefix,
+		messageCacheTTL:      cfg.MessageCacheTTL,
+		leaderKey:            cfg.LeaderKey,
+		controlChannel:       cfg.ControlChannel,
+		recipientLimitPrefix: cfg.RecipientLimitPrefix,
 	}
 }
 
@@ -43,10 +86,52 @@ func (r *Repository) SetServiceStatus(ctx context.Context, status model.ServiceS
 	return r.client.Set(ctx, r.serviceStatusKey, string(status), 0).Err()
 }
 
-func (r *Repository) CacheMessageSent(ctx context.Context, messageID string, sentAt time.Time) error {
+func (r *Repository) PublishControl(ctx context.Context, action model.ActionType) error {
+	return r.client.Publish(ctx, r.controlChannel, string(action)).Err()
+}
+
+func (r *Repository) SubscribeControl(ctx context.Context) (<-chan model.ActionType, error) {
+	pubsub := r.client.Subscribe(ctx, r.controlChannel)
+	if _, err := pubsub.Receive(ctx); err != nil {
+		_ = pubsub.Close()
+		return nil, err
+	}
+
+	actions := make(chan model.ActionType)
+	go func() {
+		defer close(actions)
+		defer pubsub.Close()
+
+		messages := pubsub.Channel()
+		for {
+			select {
+			case msg, ok := <-messages:
+				if !ok {
+					return
+				}
+				action := model.ActionType(msg.Payload)
+				if !action.IsValid() {
+					continue
+				}
+				select {
+				case actions <- action:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return actions, nil
+}
+
+func (r *Repository) CacheMessageSent(ctx context.Context, messageID string, id uint, sentAt time.Time) error {
 	key := r.sentMessagesPrefix + messageID
 	data, err := json.Marshal(map[string]interface{}{
 		"messageId": messageID,
+		"id":        id,
 		"sentAt":    sentAt,
 	})
 	if err != nil {
@@ -64,6 +149,27 @@ func (r *Repository) IsMessageSent(ctx context.Context, messageID string) (bool,
 	return exists > 0, nil
 }
 
+func (r *Repository) GetCachedMessageID(ctx context.Context, messageID string) (uint, error) {
+	key := r.sentMessagesPrefix + messageID
+	data, err := r.client.Get(ctx, key).Result()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	// Entries cached before the ID was stored decode to 0
+	var messageData struct {
+		ID uint `json:"id"`
+	}
+	if err := json.Unmarshal([]byte(data), &messageData); err != nil {
+		return 0, err
+	}
+
+	return messageData.ID, nil
+}
+
 func (r *Repository) GetCachedSentMessages(ctx context.Context) (map[string]time.Time, error) {
 	keys, err := r.client.Keys(ctx, r.sentMessagesPrefix+"*").Result()
 	if err != nil {
@@ -102,3 +208,30 @@ func (r *Repository) GetCachedSentMessages(ctx context.Context) (map[string]time
 
 	return result, nil
 }
+
+func (r *Repository) AcquireLeadership(ctx context.Context, instanceID string, ttl time.Duration) (bool, error) {
+	acquired, err := acquireLeadershipScript.Run(ctx, r.client, []string{r.leaderKey}, instanceID, ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, err
+	}
+	return acquired == 1, nil
+}
+
+func (r *Repository) ReleaseLeadership(ctx context.Context, instanceID string) error {
+	return releaseLeadershipScript.Run(ctx, r.client, []string{r.leaderKey}, instanceID).Err()
+}
+
+func (r *Repository) ReserveRecipientSend(ctx context.Context, recipient, sendID string, limit int, window time.Duration) (time.Duration, error) {
+	key := r.recipientLimitPrefix + recipient
+	now := time.Now().UnixMilli()
+
+	wait, err := reserveRecipientSendScript.Run(ctx, r.client, []string{key}, now, window.Milliseconds(), limit, sendID).Int64()
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(wait) * time.Millisecond, nil
+}
+
+func (r *Repository) ReleaseRecipientSend(ctx context.Context, recipient, sendID string) error {
+	return r.client.ZRem(ctx, r.recipientLimitPrefix+recipient, sendID).Err()
+}
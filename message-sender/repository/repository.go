@@ -2,26 +2,106 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"message-sender/model"
 )
 
+var (
+	ErrMessageNotFound   = errors.New("message not found")
+	ErrMessageNotPending = errors.New("message is not pending")
+	ErrClaimLost         = errors.New("message is no longer claimed by this worker")
+)
+
 type Repository interface {
-	GetUnsentMessages(ctx context.Context, limit int) ([]model.Message, error)
-	MarkMessageAsSent(ctx context.Context, id uint, messageID string, sentAt time.Time) error
+	ClaimMessages(ctx context.Context, workerID string, priority model.MessagePriority, limit int, lease time.Duration) ([]model.Message, error)
+	// RenewClaim extends the lease of a message workerID still holds. It
+	// returns false once the claim is lost, for example because the lease
+	// expired and the message was handed back to the queue.
+	RenewClaim(ctx context.Context, id uint, workerID string, lease time.Duration) (bool, error)
+	ReleaseExpiredClaims(ctx context.Context) (int64, error)
+	ExpireMessages(ctx context.Context) (int64, error)
+	NextScheduledAt(ctx context.Context) (time.Time, error)
+	LaneBacklog(ctx context.Context) (map[model.MessagePriority]model.LaneBacklog, error)
+	// TransitionMessage returns ErrClaimLost when change.ClaimedBy is set and
+	// that worker no longer holds the message.
+	TransitionMessage(ctx context.Context, id uint, to model.MessageStatus, change model.StatusChange) error
 	GetSentMessages(ctx context.Context, page, limit int) ([]model.Message, int, error)
+	// ListMessages returns up to limit messages matching filter on the page
+	// that cursor points to, newest first. The zero cursor is the first page.
+	ListMessages(ctx context.Context, filter model.MessageFilter, cursor model.PageCursor, limit int) ([]model.Message, error)
+	CountMessages(ctx context.Context, filter model.MessageFilter) (int, error)
+	// ExportMessages calls fn for every message matching filter, oldest
+	// first, reading fetchSize rows from the database at a time. It stops at
+	// the first error fn returns.
+	ExportMessages(ctx context.Context, filter model.MessageFilter, fetchSize int, fn func(model.Message) error) error
+	GetDeadMessages(ctx context.Context, page, limit int) ([]model.Message, int, error)
+	// RequeueDeadMessages requeues the dead messages among ids. An empty list
+	// requeues nothing.
+	RequeueDeadMessages(ctx context.Context, ids []uint) (int64, error)
+	RequeueAllDeadMessages(ctx context.Context) (int64, error)
 	GetMessageByID(ctx context.Context, id uint) (*model.Message, error)
+	GetMessageByExternalID(ctx context.Context, messageID string) (*model.Message, error)
+	GetStatusHistory(ctx context.Context, id uint) ([]model.StatusHistoryEntry, error)
 	SaveMessage(ctx context.Context, message *model.Message) error
+	// UpdatePendingMessage locks a queued or failed message, lets update
+	// change its content, recipient, send_at and expires_at and stores the
+	// result. It returns ErrMessageNotPending once a worker has claimed the
+	// message.
+	UpdatePendingMessage(ctx context.Context, id uint, update func(*model.Message) error) (*model.Message, error)
+	// CancelPendingMessage moves a queued or failed message to the cancelled
+	// state. It returns ErrMessageNotPending once a worker has claimed the
+	// message.
+	CancelPendingMessage(ctx context.Context, id uint, reason string) (*model.Message, error)
+	// SaveMessages inserts messages in one statement and returns how many
+	// were inserted.
+	SaveMessages(ctx context.Context, messages []model.Message) (int64, error)
+	ImportRepository
+}
+
+// ImportRepository keeps track of bulk import jobs and their row errors.
+type ImportRepository interface {
+	CreateImportJob(ctx context.Context, job *model.ImportJob) error
+	UpdateImportJob(ctx context.Context, job *model.ImportJob) error
+	GetImportJob(ctx context.Context, id uint) (*model.ImportJob, error)
+	SaveImportErrors(ctx context.Context, jobID uint, rowErrors []model.ImportRowError) error
+	GetImportErrors(ctx context.Context, jobID uint, page, limit int) ([]model.ImportRowError, int, error)
 }
 
 type ServiceStatusRepository interface {
 	GetServiceStatus(ctx context.Context) (model.ServiceStatus, error)
 	SetServiceStatus(ctx context.Context, status model.ServiceStatus) error
+	PublishControl(ctx context.Context, action model.ActionType) error
+	// SubscribeControl delivers control commands broadcast by any instance
+	// until ctx is done, after which the channel is closed.
+	SubscribeControl(ctx context.Context) (<-chan model.ActionType, error)
 }
 
 type CacheRepository interface {
-	CacheMessageSent(ctx context.Context, messageID string, sentAt time.Time) error
+	CacheMessageSent(ctx context.Context, messageID string, id uint, sentAt time.Time) error
 	IsMessageSent(ctx context.Context, messageID string) (bool, error)
+	// GetCachedMessageID returns the ID of the message the provider knows as
+	// messageID, or 0 when it is not cached.
+	GetCachedMessageID(ctx context.Context, messageID string) (uint, error)
 	GetCachedSentMessages(ctx context.Context) (map[string]time.Time, error)
 }
+
+// LeaderRepository backs a renewable lock that at most one instance holds at
+// a time.
+type LeaderRepository interface {
+	AcquireLeadership(ctx context.Context, instanceID string, ttl time.Duration) (bool, error)
+	ReleaseLeadership(ctx context.Context, instanceID string) error
+}
+
+// RecipientLimitRepository keeps a sliding window of the sends to every
+// recipient, shared by all instances.
+type RecipientLimitRepository interface {
+	// ReserveRecipientSend records a send to recipient unless limit sends were
+	// already recorded within window. When the limit is reached nothing is
+	// recorded and the time until the oldest send leaves the window is returned.
+	ReserveRecipientSend(ctx context.Context, recipient, sendID string, limit int, window time.Duration) (time.Duration, error)
+	// ReleaseRecipientSend removes a send recorded by ReserveRecipientSend that
+	// did not go out.
+	ReleaseRecipientSend(ctx context.Context, recipient, sendID string) error
+}
@@ -0,0 +1,242 @@
+package sender
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"message-sender/config"
+	"message-sender/model"
+)
+
+const (
+	defaultBreakerFailureRatio = 0.5
+	defaultBreakerMinRequests  = 10
+	defaultBreakerWindowSize   = 20
+	defaultBreakerCoolDown     = 30 * time.Second
+)
+
+// ErrCircuitOpen is returned for sends rejected by an open circuit breaker. It
+// matches ErrUnavailable so the next provider is tried.
+var ErrCircuitOpen = &unavailableError{err: errors.New("circuit breaker is open")}
+
+// Breaker is a circuit breaker over the outcome of the last sends to one
+// provider. It opens when the share of unavailable responses in the window
+// reaches the failure ratio, rejects sends for the cool-down, then lets a
+// single probe through: a success closes it and a failure opens it again.
+type Breaker struct {
+	provider     string
+	failureRatio float64
+	minRequests  int
+	coolDown     time.Duration
+	logger       *zap.Logger
+	now          func() time.Time
+
+	mu       sync.Mutex
+	state    model.BreakerState
+	window   []bool // true for a failure
+	next     int
+	count    int
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+func NewBreaker(provider string, cfg config.BreakerConfig, logger *zap.Logger) *Breaker {
+	b := &Breaker{
+		provider:     provider,
+		failureRatio: cfg.FailureRatio,
+		minRequests:  cfg.MinRequests,
+		coolDown:     cfg.CoolDown,
+		logger:       logger,
+		now:          time.Now,
+		state:        model.BreakerClosed,
+	}
+
+	if b.failureRatio <= 0 || b.failureRatio > 1 {
+		b.failureRatio = defaultBreakerFailureRatio
+	}
+	windowSize := cfg.WindowSize
+	if windowSize <= 0 {
+		windowSize = defaultBreakerWindowSize
+	}
+	if b.minRequests <= 0 {
+		b.minRequests = defaultBreakerMinRequests
+	}
+	if b.minRequests > windowSize {
+		b.minRequests = windowSize
+	}
+	if b.coolDown <= 0 {
+		b.coolDown = defaultBreakerCoolDown
+	}
+	b.window = make([]bool, windowSize)
+
+	return b
+}
+
+// Ready reports whether a send would be let through, without taking the
+// half-open probe.
+func (b *Breaker) Ready() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case model.BreakerOpen:
+		return !b.now().Before(b.openedAt.Add(b.coolDown))
+	case model.BreakerHalfOpen:
+		return !b.probing
+	default:
+		return true
+	}
+}
+
+// allow reports whether a send may go through. After the cool-down the first
+// caller moves the breaker to half-open and becomes the probe.
+func (b *Breaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case model.BreakerOpen:
+		if b.now().Before(b.openedAt.Add(b.coolDown)) {
+			return false
+		}
+		b.setState(model.BreakerHalfOpen)
+		b.probing = true
+		return true
+	case model.BreakerHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+// record adds the outcome of a send that allow let through.
+func (b *Breaker) record(failure bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == model.BreakerHalfOpen {
+		b.probing = false
+		if failure {
+			b.open()
+		} else {
+			b.reset()
+			b.setState(model.BreakerClosed)
+		}
+		return
+	}
+
+	if b.count == len(b.window) {
+		if b.window[b.next] {
+			b.failures--
+		}
+	} else {
+		b.count++
+	}
+	b.window[b.next] = failure
+	if failure {
+		b.failures++
+	}
+	b.next = (b.next + 1) % len(b.window)
+
+	if b.state == model.BreakerClosed && b.count >= b.minRequests &&
+		float64(b.failures)/float64(b.count) >= b.failureRatio {
+		b.open()
+	}
+}
+
+// skip gives up a send that allow let through without an outcome, for example
+// when it was cancelled by shutdown.
+func (b *Breaker) skip() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+}
+
+func (b *Breaker) open() {
+	b.openedAt = b.now()
+	b.setState(model.BreakerOpen)
+}
+
+func (b *Breaker) reset() {
+	b.next, b.count, b.failures = 0, 0, 0
+}
+
+func (b *Breaker) setState(state model.BreakerState) {
+	if b.state == state {
+		return
+	}
+
+	from := b.state
+	b.state = state
+
+	fields := []zap.Field{
+		zap.String("provider", b.provider),
+		zap.String("from", string(from)),
+		zap.String("to", string(state)),
+	}
+	if state == model.BreakerOpen {
+		b.logger.Warn("Circuit breaker opened", append(fields,
+			zap.Int("failures", b.failures),
+			zap.Int("requests", b.count),
+			zap.Duration("coolDown", b.coolDown))...)
+		return
+	}
+	b.logger.Info("Circuit breaker state changed", fields...)
+}
+
+func (b *Breaker) Info() model.BreakerInfo {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	info := model.BreakerInfo{
+		Provider: b.provider,
+		State:    b.state,
+		Requests: b.count,
+		Failures: b.failures,
+	}
+	if b.count > 0 {
+		info.FailureRatio = float64(b.failures) / float64(b.count)
+	}
+	if b.state != model.BreakerClosed {
+		info.OpenedAt = b.openedAt
+		info.RetryAt = b.openedAt.Add(b.coolDown)
+	}
+	return info
+}
+
+// breakerSender guards a sender with a circuit breaker. Only unavailable
+// errors count as failures; a rejected message still shows the provider is up.
+type breakerSender struct {
+	Sender
+	breaker *Breaker
+}
+
+func (s *breakerSender) Send(ctx context.Context, msg model.Message) (string, error) {
+	if !s.breaker.allow() {
+		return "", fmt.Errorf("provider %s: %w", s.Name(), ErrCircuitOpen)
+	}
+
+	messageID, err := s.Sender.Send(ctx, msg)
+	switch {
+	case err != nil && ctx.Err() != nil, errors.Is(err, ErrThrottled):
+		// Neither says anything about the provider's health
+		s.breaker.skip()
+	case errors.Is(err, ErrUnavailable):
+		s.breaker.record(true)
+	default:
+		s.breaker.record(false)
+	}
+
+	return messageID, err
+}
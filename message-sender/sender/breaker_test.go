@@ -0,0 +1,110 @@
+package sender
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zaptest"
+
+	"message-sender/config"
+	"message-sender/model"
+)
+
+func TestBreaker_Transitions(t *testing.T) {
+	now := time.Now()
+	cfg := config.BreakerConfig{FailureRatio: 0.5, MinRequests: 4, WindowSize: 4, CoolDown: time.Minute}
+	breaker := NewBreaker("primary", cfg, zaptest.NewLogger(t))
+	breaker.now = func() time.Time { return now }
+
+	// Below the minimum number of requests the breaker stays closed
+	breaker.record(true)
+	breaker.record(true)
+	breaker.record(false)
+	if state := breaker.Info().State; state != model.BreakerClosed {
+		t.Fatalf("Expected closed below the minimum requests, got %s", state)
+	}
+
+	breaker.record(false)
+	if state := breaker.Info().State; state != model.BreakerOpen {
+		t.Fatalf("Expected open at a failure ratio of 0.5, got %s", state)
+	}
+	if breaker.Ready() || breaker.allow() {
+		t.Fatal("Expected an open breaker to reject sends")
+	}
+
+	// After the cool-down a single probe is let through
+	now = now.Add(time.Minute)
+	if !breaker.Ready() || !breaker.allow() {
+		t.Fatal("Expected a probe after the cool-down")
+	}
+	if state := breaker.Info().State; state != model.BreakerHalfOpen {
+		t.Fatalf("Expected half-open while probing, got %s", state)
+	}
+	if breaker.Ready() || breaker.allow() {
+		t.Fatal("Expected only one probe at a time")
+	}
+
+	breaker.record(true)
+	if state := breaker.Info().State; state != model.BreakerOpen {
+		t.Fatalf("Expected a failed probe to open the breaker again, got %s", state)
+	}
+
+	now = now.Add(time.Minute)
+	if !breaker.allow() {
+		t.Fatal("Expected a probe after the second cool-down")
+	}
+	breaker.record(false)
+
+	info := breaker.Info()
+	if info.State != model.BreakerClosed || info.Requests != 0 {
+		t.Errorf("Expected a successful probe to close and reset the breaker, got %+v", info)
+	}
+}
+
+func TestRegistry_UseBreakers(t *testing.T) {
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer down.Close()
+
+	registry, err := NewRegistry("",
+		NewWebhookSender("primary", config.WebhookConfig{URL: down.URL}),
+		NewWebhookSender("backup", config.WebhookConfig{URL: down.URL}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	registry.UseBreakers(config.BreakerConfig{MinRequests: 1, WindowSize: 1, CoolDown: time.Hour}, zaptest.NewLogger(t))
+
+	primary, _ := registry.Get("primary")
+	if _, err := primary.Send(context.Background(), model.Message{ID: 1}); !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("Expected an unavailable error, got %v", err)
+	}
+
+	if _, err := primary.Send(context.Background(), model.Message{ID: 1}); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Expected the open breaker to reject the send, got %v", err)
+	}
+
+	candidates := registry.Candidates("primary")
+	if len(candidates) != 1 || candidates[0].Name() != "backup" {
+		t.Errorf("Expected only backup as a candidate, got %d candidates", len(candidates))
+	}
+	if !registry.Available() {
+		t.Error("Expected the registry to be available while backup is closed")
+	}
+
+	backup, _ := registry.Get("backup")
+	_, _ = backup.Send(context.Background(), model.Message{ID: 1})
+	if registry.Available() {
+		t.Error("Expected the registry to be unavailable once every breaker is open")
+	}
+
+	breakers := registry.Breakers()
+	if len(breakers) != 2 || breakers[0].Provider != "primary" || breakers[0].State != model.BreakerOpen {
+		t.Errorf("Expected both breakers to be reported open, got %+v", breakers)
+	}
+}
@@ -0,0 +1,208 @@
+package sender
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"message-sender/model"
+)
+
+// defaultReloadInterval is how often the rules file is checked for changes
+// when no interval is configured.
+const defaultReloadInterval = 10 * time.Second
+
+var prefixPattern = regexp.MustCompile(`^\+?[0-9]*$`)
+
+// Route is the outcome of the routing table for one message. An empty
+// Provider means no rule matched and the registry picks the provider.
+type Route struct {
+	Rule     *model.RoutingRule
+	Provider string
+	SenderID string
+}
+
+// Router maps recipient prefixes and message tags to a provider and sender ID.
+// The rules are loaded from a JSON file and can be reloaded while running.
+type Router struct {
+	path     string
+	registry *Registry
+	logger   *zap.Logger
+
+	mu      sync.RWMutex
+	rules   []model.RoutingRule
+	modTime time.Time
+}
+
+// NewRouter loads the routing rules from path. Without a path the router has
+// no rules and every message goes to the registry's choice of provider.
+func NewRouter(path string, registry *Registry, logger *zap.Logger) (*Router, error) {
+	r := &Router{path: path, registry: registry, logger: logger}
+	if path == "" {
+		return r, nil
+	}
+
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload reads the rules file again. The current rules are kept when the file
+// cannot be read or contains an invalid rule.
+func (r *Router) Reload() error {
+	info, err := os.Stat(r.path)
+	if err != nil {
+		return fmt.Errorf("failed to stat routing rules: %w", err)
+	}
+
+	data, err := os.ReadFile(r.path)
+	if err != nil {
+		return fmt.Errorf("failed to read routing rules: %w", err)
+	}
+
+	var rules []model.RoutingRule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return fmt.Errorf("failed to parse routing rules: %w", err)
+	}
+
+	if err := r.SetRules(rules); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	r.modTime = info.ModTime()
+	r.mu.Unlock()
+
+	return nil
+}
+
+// SetRules validates and replaces the routing rules. Prefixes are stored in
+// E.164 form, so "90" and "+90" are the same rule.
+func (r *Router) SetRules(rules []model.RoutingRule) error {
+	normalized := make([]model.RoutingRule, len(rules))
+	for i, rule := range rules {
+		if !prefixPattern.MatchString(rule.Prefix) {
+			return fmt.Errorf("routing rule %d: invalid prefix %q", i, rule.Prefix)
+		}
+		if _, err := r.registry.Get(rule.Provider); err != nil {
+			return fmt.Errorf("routing rule %d: %w", i, err)
+		}
+
+		if rule.Prefix != "" && !strings.HasPrefix(rule.Prefix, "+") {
+			rule.Prefix = "+" + rule.Prefix
+		}
+		normalized[i] = rule
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.rules = normalized
+	return nil
+}
+
+// Watch reloads the rules file whenever it changes, checking every interval
+// until ctx is done.
+func (r *Router) Watch(ctx context.Context, interval time.Duration) error {
+	if r.path == "" {
+		<-ctx.Done()
+		return nil
+	}
+
+	if interval <= 0 {
+		interval = defaultReloadInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			r.reloadIfChanged()
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (r *Router) reloadIfChanged() {
+	info, err := os.Stat(r.path)
+	if err != nil {
+		r.logger.Error("Failed to check routing rules", zap.Error(err))
+		return
+	}
+
+	r.mu.RLock()
+	unchanged := info.ModTime().Equal(r.modTime)
+	r.mu.RUnlock()
+	if unchanged {
+		return
+	}
+
+	if err := r.Reload(); err != nil {
+		r.logger.Error("Failed to reload routing rules, keeping the current rules", zap.Error(err))
+		return
+	}
+
+	r.logger.Info("Routing rules reloaded", zap.String("path", r.path), zap.Int("rules", r.ruleCount()))
+}
+
+func (r *Router) ruleCount() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return len(r.rules)
+}
+
+// Resolve returns the rule with the longest prefix matching recipient. A rule
+// with tags only matches messages carrying one of them, and wins over a rule
+// without tags for the same prefix. Earlier rules win remaining ties.
+func (r *Router) Resolve(recipient string, tags []string) Route {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var best *model.RoutingRule
+	for i := range r.rules {
+		rule := &r.rules[i]
+		if !strings.HasPrefix(recipient, rule.Prefix) || !matchesTags(rule.Tags, tags) {
+			continue
+		}
+
+		if best == nil ||
+			len(rule.Prefix) > len(best.Prefix) ||
+			(len(rule.Prefix) == len(best.Prefix) && len(rule.Tags) > 0 && len(best.Tags) == 0) {
+			best = rule
+		}
+	}
+
+	if best == nil {
+		return Route{}
+	}
+
+	rule := *best
+	return Route{Rule: &rule, Provider: rule.Provider, SenderID: rule.SenderID}
+}
+
+func matchesTags(ruleTags, tags []string) bool {
+	if len(ruleTags) == 0 {
+		return true
+	}
+
+	for _, ruleTag := range ruleTags {
+		for _, tag := range tags {
+			if ruleTag == tag {
+				return true
+			}
+		}
+	}
+	return false
+}
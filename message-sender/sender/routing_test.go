@@ -0,0 +1,114 @@
+package sender
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zaptest"
+
+	"message-sender/config"
+	"message-sender/model"
+)
+
+func newTestRouter(t *testing.T, path string) *Router {
+	t.Helper()
+
+	registry, err := NewRegistry("",
+		NewWebhookSender("default", config.WebhookConfig{}),
+		NewWebhookSender("turkey", config.WebhookConfig{}),
+		NewWebhookSender("london", config.WebhookConfig{}),
+		NewWebhookSender("otp", config.WebhookConfig{}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	router, err := NewRouter(path, registry, zaptest.NewLogger(t))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return router
+}
+
+func TestRouter_Resolve(t *testing.T) {
+	router := newTestRouter(t, "")
+	err := router.SetRules([]model.RoutingRule{
+		{Prefix: "+44", Provider: "default"},
+		{Prefix: "4420", Provider: "london", SenderID: "LDN"},
+		{Prefix: "+90", Provider: "turkey", SenderID: "INSIDER"},
+		{Prefix: "+90", Tags: []string{"otp"}, Provider: "otp"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		recipient string
+		tags      []string
+		provider  string
+		senderID  string
+	}{
+		{name: "longest prefix wins", recipient: "+442071234567", provider: "london", senderID: "LDN"},
+		{name: "shorter prefix", recipient: "+441611234567", provider: "default"},
+		{name: "prefix without tags", recipient: "+905551111111", tags: []string{"marketing"}, provider: "turkey", senderID: "INSIDER"},
+		{name: "tagged rule wins the same prefix", recipient: "+905551111111", tags: []string{"otp"}, provider: "otp"},
+		{name: "no match", recipient: "+15551234567", provider: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			route := router.Resolve(tt.recipient, tt.tags)
+			if route.Provider != tt.provider || route.SenderID != tt.senderID {
+				t.Errorf("Expected %s/%s, got %s/%s", tt.provider, tt.senderID, route.Provider, route.SenderID)
+			}
+			if (route.Rule == nil) != (tt.provider == "") {
+				t.Errorf("Expected a matched rule only when a provider is routed, got %+v", route.Rule)
+			}
+		})
+	}
+
+	if err := router.SetRules([]model.RoutingRule{{Prefix: "+90", Provider: "missing"}}); err == nil {
+		t.Error("Expected an error for an unknown provider")
+	}
+	if err := router.SetRules([]model.RoutingRule{{Prefix: "+9o", Provider: "turkey"}}); err == nil {
+		t.Error("Expected an error for an invalid prefix")
+	}
+	if route := router.Resolve("+905551111111", nil); route.Provider != "turkey" {
+		t.Errorf("Expected invalid rules to keep the current rules, got %s", route.Provider)
+	}
+}
+
+func TestRouter_ReloadsChangedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "routes.json")
+	write := func(content string, modTime time.Time) {
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	start := time.Now().Add(-time.Hour)
+	write(`[{"prefix":"+90","provider":"turkey"}]`, start)
+	router := newTestRouter(t, path)
+
+	if route := router.Resolve("+905551111111", nil); route.Provider != "turkey" {
+		t.Fatalf("Expected turkey, got %s", route.Provider)
+	}
+
+	write(`[{"prefix":"+90","provider":"otp"}]`, start.Add(time.Minute))
+	router.reloadIfChanged()
+	if route := router.Resolve("+905551111111", nil); route.Provider != "otp" {
+		t.Errorf("Expected the changed file to be reloaded, got %s", route.Provider)
+	}
+
+	write(`[{"prefix":"+90",`, start.Add(2*time.Minute))
+	router.reloadIfChanged()
+	if route := router.Resolve("+905551111111", nil); route.Provider != "otp" {
+		t.Errorf("Expected an invalid file to keep the current rules, got %s", route.Provider)
+	}
+}
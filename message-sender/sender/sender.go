@@ -0,0 +1,336 @@
+package sender
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"message-sender/config"
+	"message-sender/model"
+)
+
+var (
+	ErrUnknownProvider = errors.New("unknown provider")
+
+	// ErrNoProviderReady is returned when every provider is held back by its
+	// circuit breaker or throttle.
+	ErrNoProviderReady = errors.New("no provider is ready")
+
+	// ErrUnavailable matches send errors that another provider may not have,
+	// such as a 5xx response or a timeout. Only these trigger a failover.
+	ErrUnavailable = errors.New("provider unavailable")
+)
+
+// unavailableError marks err as matching ErrUnavailable while keeping its
+// message.
+type unavailableError struct {
+	err error
+}
+
+func (e *unavailableError) Error() string {
+	return e.err.Error()
+}
+
+func (e *unavailableError) Unwrap() error {
+	return e.err
+}
+
+func (e *unavailableError) Is(target error) bool {
+	return target == ErrUnavailable
+}
+
+// Sender delivers a message through one provider.
+type Sender interface {
+	// Name is the provider name the sender is registered under.
+	Name() string
+
+	// Send delivers msg and returns the provider's message ID, which may be
+	// empty if the provider does not return one.
+	Send(ctx context.Context, msg model.Message) (string, error)
+}
+
+// Registry holds the configured senders by provider name and decides the
+// order in which they are tried for a message.
+type Registry struct {
+	senders     map[string]Sender
+	names       []string
+	defaultName string
+
+	// Smooth weighted round robin state, only used when weights are set.
+	weightMux sync.Mutex
+	weights   map[string]int
+	current   map[string]int
+
+	breakers  map[string]*Breaker
+	throttles map[string]*Throttle
+}
+
+// NewRegistry registers senders in the given order. defaultName selects the
+// sender returned by Default; the first sender is used when it is empty.
+func NewRegistry(defaultName string, senders ...Sender) (*Registry, error) {
+	if len(senders) == 0 {
+		return nil, errors.New("at least one sender is required")
+	}
+
+	r := &Registry{senders: make(map[string]Sender, len(senders))}
+	for _, s := range senders {
+		if _, ok := r.senders[s.Name()]; ok {
+			return nil, fmt.Errorf("duplicate provider %q", s.Name())
+		}
+		r.senders[s.Name()] = s
+		r.names = append(r.names, s.Name())
+	}
+
+	if defaultName == "" {
+		defaultName = r.names[0]
+	}
+	if _, ok := r.senders[defaultName]; !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownProvider, defaultName)
+	}
+	r.defaultName = defaultName
+
+	return r, nil
+}
+
+// NewRegistryFromConfig builds a sender for every configured provider.
+func NewRegistryFromConfig(cfg config.SenderConfig) (*Registry, error) {
+	senders := make([]Sender, 0, len(cfg.Providers))
+	for _, name := range cfg.Providers {
+		providerCfg := cfg.Provider[name]
+
+		switch providerCfg.Type {
+		case config.ProviderTypeWebhook:
+			senders = append(senders, NewWebhookSender(name, providerCfg.WebhookConfig))
+		default:
+			return nil, fmt.Errorf("provider %s: unsupported type %q", name, providerCfg.Type)
+		}
+	}
+
+	r, err := NewRegistry(cfg.DefaultProvider, senders...)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Strategy == config.StrategyWeighted {
+		weights := make(map[string]int, len(cfg.Providers))
+		for _, name := range cfg.Providers {
+			weights[name] = cfg.Provider[name].Weight
+		}
+		if err := r.SetWeights(weights); err != nil {
+			return nil, err
+		}
+	}
+
+	return r, nil
+}
+
+// SetWeights switches the registry to weighted round robin. Providers missing
+// from weights get a weight of 1; a weight of 0 keeps a provider out of the
+// rotation but still available for failover.
+func (r *Registry) SetWeights(weights map[string]int) error {
+	resolved := make(map[string]int, len(r.names))
+	for _, name := range r.names {
+		weight, ok := weights[name]
+		if !ok {
+			weight = 1
+		}
+		if weight < 0 {
+			return fmt.Errorf("provider %s: weight must not be negative", name)
+		}
+		resolved[name] = weight
+	}
+	for name := range weights {
+		if _, ok := r.senders[name]; !ok {
+			return fmt.Errorf("%w: %s", ErrUnknownProvider, name)
+		}
+	}
+
+	r.weightMux.Lock()
+	defer r.weightMux.Unlock()
+
+	r.weights = resolved
+	r.current = make(map[string]int, len(resolved))
+	return nil
+}
+
+// Get returns the sender registered under name.
+func (r *Registry) Get(name string) (Sender, error) {
+	s, ok := r.senders[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownProvider, name)
+	}
+	return s, nil
+}
+
+// Default returns the sender used when no provider is chosen explicitly.
+func (r *Registry) Default() Sender {
+	return r.senders[r.defaultName]
+}
+
+// UseBreakers guards every sender with its own circuit breaker. Must be
+// called before the registry is used.
+func (r *Registry) UseBreakers(cfg config.BreakerConfig, logger *zap.Logger) {
+	r.breakers = make(map[string]*Breaker, len(r.names))
+	for _, name := range r.names {
+		breaker := NewBreaker(name, cfg, logger)
+		r.breakers[name] = breaker
+		r.senders[name] = &breakerSender{Sender: r.senders[name], breaker: breaker}
+	}
+}
+
+// UseThrottling paces every sender with its own adaptive throttle. Must be
+// called before the registry is used.
+func (r *Registry) UseThrottling(cfg config.ThrottleConfig, logger *zap.Logger) {
+	r.throttles = make(map[string]*Throttle, len(r.names))
+	for _, name := range r.names {
+		throttle := NewThrottle(name, cfg, logger)
+		r.throttles[name] = throttle
+		r.senders[name] = &throttledSender{Sender: r.senders[name], throttle: throttle}
+	}
+}
+
+// MaxThrottleWait is the longest a send can wait for the throttle of its
+// provider with queued sends ahead of it, or 0 without throttling.
+func (r *Registry) MaxThrottleWait(queued int) time.Duration {
+	var longest time.Duration
+	for _, throttle := range r.throttles {
+		if wait := throttle.maxWait(queued); wait > longest {
+			longest = wait
+		}
+	}
+	return longest
+}
+
+// Candidates returns the senders to try for one message, in order. The first
+// one is preferred when it is set, for example by a routing rule; otherwise it
+// is the default provider, or the next provider in the weighted rotation when
+// weights are set. The others follow in registration order as failover
+// targets. Providers whose circuit breaker is open or that are paused by their
+// throttle are left out, so the result is empty when none can take traffic.
+func (r *Registry) Candidates(preferred string) []Sender {
+	first := preferred
+	if _, ok := r.senders[first]; !ok {
+		first = r.next()
+	}
+
+	candidates := make([]Sender, 0, len(r.names))
+	if r.ready(first) {
+		candidates = append(candidates, r.senders[first])
+	}
+	for _, name := range r.names {
+		if name != first && r.ready(name) {
+			candidates = append(candidates, r.senders[name])
+		}
+	}
+	return candidates
+}
+
+// Available reports whether at least one provider can take traffic.
+func (r *Registry) Available() bool {
+	for _, name := range r.names {
+		if r.ready(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Breakers returns the state of every circuit breaker in registration order,
+// or nothing when breakers are not used.
+func (r *Registry) Breakers() []model.BreakerInfo {
+	infos := make([]model.BreakerInfo, 0, len(r.breakers))
+	for _, name := range r.names {
+		if breaker, ok := r.breakers[name]; ok {
+			infos = append(infos, breaker.Info())
+		}
+	}
+	return infos
+}
+
+// Throttles returns the state of every throttle in registration order, or
+// nothing when throttling is not used.
+func (r *Registry) Throttles() []model.ThrottleInfo {
+	infos := make([]model.ThrottleInfo, 0, len(r.throttles))
+	for _, name := range r.names {
+		if throttle, ok := r.throttles[name]; ok {
+			infos = append(infos, throttle.Info())
+		}
+	}
+	return infos
+}
+
+// ResumeAt returns the earliest time at which a provider that is held back
+// can take traffic again, or the zero time when one is ready now.
+func (r *Registry) ResumeAt() time.Time {
+	var earliest time.Time
+	for _, name := range r.names {
+		var at time.Time
+		if breaker, ok := r.breakers[name]; ok && !breaker.Ready() {
+			at = breaker.Info().RetryAt
+		}
+		if throttle, ok := r.throttles[name]; ok {
+			if pausedUntil := throttle.PausedUntil(); pausedUntil.After(at) {
+				at = pausedUntil
+			}
+		}
+
+		if at.IsZero() {
+			return time.Time{}
+		}
+		if earliest.IsZero() || at.Before(earliest) {
+			earliest = at
+		}
+	}
+	return earliest
+}
+
+func (r *Registry) ready(name string) bool {
+	if breaker, ok := r.breakers[name]; ok && !breaker.Ready() {
+		return false
+	}
+	if throttle, ok := r.throttles[name]; ok && !throttle.Ready() {
+		return false
+	}
+	return true
+}
+
+// next picks the provider to try first using smooth weighted round robin, which
+// spreads the picks of each provider evenly instead of sending them in bursts.
+func (r *Registry) next() string {
+	r.weightMux.Lock()
+	defer r.weightMux.Unlock()
+
+	if r.weights == nil {
+		return r.defaultName
+	}
+
+	best := ""
+	total := 0
+	for _, name := range r.names {
+		weight := r.weights[name]
+		if weight == 0 {
+			continue
+		}
+		total += weight
+		r.current[name] += weight
+		if best == "" || r.current[name] > r.current[best] {
+			best = name
+		}
+	}
+
+	if best == "" {
+		return r.defaultName
+	}
+
+	r.current[best] -= total
+	return best
+}
+
+// Names returns the registered provider names in registration order.
+func (r *Registry) Names() []string {
+	return append([]string(nil), r.names...)
+}
@@ -0,0 +1,163 @@
+package sender
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"message-sender/config"
+	"message-sender/model"
+)
+
+func TestNewRegistryFromConfig(t *testing.T) {
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte(`{"messageId":"primary-id"}`))
+	}))
+	defer primary.Close()
+
+	backup := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"data":{"id":"backup-id"}}`))
+	}))
+	defer backup.Close()
+
+	cfg := config.SenderConfig{
+		Providers:       []string{"primary", "backup"},
+		DefaultProvider: "backup",
+		Provider: map[string]config.ProviderConfig{
+			"primary": {
+				Type:          config.ProviderTypeWebhook,
+				WebhookConfig: config.WebhookConfig{URL: primary.URL, MessageIDPath: "messageId"},
+			},
+			"backup": {
+				Type:          config.ProviderTypeWebhook,
+				WebhookConfig: config.WebhookConfig{URL: backup.URL, MessageIDPath: "data.id"},
+			},
+		},
+	}
+
+	registry, err := NewRegistryFromConfig(cfg)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if names := registry.Names(); len(names) != 2 || names[0] != "primary" || names[1] != "backup" {
+		t.Errorf("Expected providers [primary backup], got %v", names)
+	}
+	if registry.Default().Name() != "backup" {
+		t.Errorf("Expected default provider backup, got %s", registry.Default().Name())
+	}
+
+	msg := model.Message{ID: 1, Content: "hello", Recipient: "+905500000000"}
+	for name, expected := range map[string]string{"primary": "primary-id", "backup": "backup-id"} {
+		s, err := registry.Get(name)
+		if err != nil {
+			t.Fatalf("Expected provider %s, got %v", name, err)
+		}
+
+		messageID, err := s.Send(context.Background(), msg)
+		if err != nil {
+			t.Fatalf("Expected %s to send, got %v", name, err)
+		}
+		if messageID != expected {
+			t.Errorf("Expected message ID %s from %s, got %s", expected, name, messageID)
+		}
+	}
+
+	if _, err := registry.Get("missing"); !errors.Is(err, ErrUnknownProvider) {
+		t.Errorf("Expected ErrUnknownProvider, got %v", err)
+	}
+
+	cfg.Provider["primary"] = config.ProviderConfig{Type: "smpp"}
+	if _, err := NewRegistryFromConfig(cfg); err == nil {
+		t.Error("Expected an error for an unsupported provider type")
+	}
+}
+
+func TestNewRegistry_RejectsDuplicateAndUnknownDefault(t *testing.T) {
+	webhook := NewWebhookSender("webhook", config.WebhookConfig{})
+
+	if _, err := NewRegistry("", webhook, NewWebhookSender("webhook", config.WebhookConfig{})); err == nil {
+		t.Error("Expected an error for duplicate providers")
+	}
+	if _, err := NewRegistry("missing", webhook); !errors.Is(err, ErrUnknownProvider) {
+		t.Errorf("Expected ErrUnknownProvider, got %v", err)
+	}
+}
+
+func TestRegistry_Candidates(t *testing.T) {
+	registry, err := NewRegistry("b",
+		NewWebhookSender("a", config.WebhookConfig{}),
+		NewWebhookSender("b", config.WebhookConfig{}),
+		NewWebhookSender("c", config.WebhookConfig{}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := func(senders []Sender) []string {
+		var result []string
+		for _, s := range senders {
+			result = append(result, s.Name())
+		}
+		return result
+	}
+
+	if got := names(registry.Candidates("")); !reflect.DeepEqual(got, []string{"b", "a", "c"}) {
+		t.Errorf("Expected the default provider first, got %v", got)
+	}
+
+	if err := registry.SetWeights(map[string]int{"a": 3, "b": 1, "c": 0}); err != nil {
+		t.Fatal(err)
+	}
+
+	picks := make(map[string]int)
+	var sequence []string
+	for i := 0; i < 8; i++ {
+		candidates := registry.Candidates("")
+		if len(candidates) != 3 {
+			t.Fatalf("Expected every provider as a candidate, got %v", names(candidates))
+		}
+		picks[candidates[0].Name()]++
+		sequence = append(sequence, candidates[0].Name())
+	}
+
+	if picks["a"] != 6 || picks["b"] != 2 || picks["c"] != 0 {
+		t.Errorf("Expected picks a=6 b=2 c=0, got %v", picks)
+	}
+	if !reflect.DeepEqual(sequence[:4], []string{"a", "a", "b", "a"}) {
+		t.Errorf("Expected picks to be interleaved, got %v", sequence)
+	}
+
+	if err := registry.SetWeights(map[string]int{"missing": 1}); !errors.Is(err, ErrUnknownProvider) {
+		t.Errorf("Expected ErrUnknownProvider, got %v", err)
+	}
+}
+
+func TestWebhookSender_MarksUnavailableErrors(t *testing.T) {
+	status := http.StatusServiceUnavailable
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+
+	webhook := NewWebhookSender("webhook", config.WebhookConfig{URL: server.URL})
+	msg := model.Message{ID: 1}
+
+	if _, err := webhook.Send(context.Background(), msg); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("Expected a 5xx response to be unavailable, got %v", err)
+	}
+
+	status = http.StatusBadRequest
+	if _, err := webhook.Send(context.Background(), msg); err == nil || errors.Is(err, ErrUnavailable) {
+		t.Errorf("Expected a 4xx response to fail without being unavailable, got %v", err)
+	}
+
+	server.Close()
+	if _, err := webhook.Send(context.Background(), msg); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("Expected a connection error to be unavailable, got %v", err)
+	}
+}
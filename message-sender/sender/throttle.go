@@ -0,0 +1,282 @@
+package sender
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"message-sender/config"
+	"message-sender/model"
+)
+
+const (
+	defaultThrottleMaxRate        = 50
+	defaultThrottleMinRate        = 1
+	defaultThrottleDecreaseFactor = 0.5
+	defaultThrottleIncreaseStep   = 1
+	defaultThrottlePause          = 5 * time.Second
+)
+
+// ErrThrottled matches send errors caused by the provider rate limiting us. A
+// throttled send is not a failed attempt.
+var ErrThrottled = errors.New("provider is throttling")
+
+// ThrottledError is returned when the provider asks us to slow down, for
+// example with 429 Too Many Requests. RetryAfter is zero when the provider did
+// not say how long to wait.
+type ThrottledError struct {
+	RetryAfter time.Duration
+	err        error
+}
+
+func (e *ThrottledError) Error() string {
+	return e.err.Error()
+}
+
+func (e *ThrottledError) Unwrap() error {
+	return e.err
+}
+
+func (e *ThrottledError) Is(target error) bool {
+	return target == ErrThrottled
+}
+
+// isThrottled reports whether a response asks us to slow down: 429, or 503
+// with a Retry-After header.
+func isThrottled(resp *http.Response) bool {
+	return resp.StatusCode == http.StatusTooManyRequests ||
+		resp.StatusCode == http.StatusServiceUnavailable && resp.Header.Get("Retry-After") != ""
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds or as an HTTP
+// date. It returns zero when the header is missing or invalid.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+
+	if at, err := http.ParseTime(value); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+
+	return 0
+}
+
+// Throttle paces the sends to one provider. It is unlimited until the
+// provider throttles us; then sending pauses for the advertised time and the
+// send rate is cut multiplicatively, and every accepted send raises it again
+// additively (AIMD) until it is back at the maximum and the limit is lifted.
+type Throttle struct {
+	provider       string
+	maxRate        float64
+	minRate        float64
+	decreaseFactor float64
+	increaseStep   float64
+	defaultPause   time.Duration
+	logger         *zap.Logger
+	now            func() time.Time
+
+	mu          sync.Mutex
+	pausedUntil time.Time
+	rate        float64 // messages per second, 0 when unlimited
+	nextSlot    time.Time
+}
+
+func NewThrottle(provider string, cfg config.ThrottleConfig, logger *zap.Logger) *Throttle {
+	t := &Throttle{
+		provider:       provider,
+		maxRate:        cfg.MaxRate,
+		minRate:        cfg.MinRate,
+		decreaseFactor: cfg.DecreaseFactor,
+		increaseStep:   cfg.IncreaseStep,
+		defaultPause:   cfg.DefaultPause,
+		logger:         logger,
+		now:            time.Now,
+	}
+
+	if t.maxRate <= 0 {
+		t.maxRate = defaultThrottleMaxRate
+	}
+	if t.minRate <= 0 {
+		t.minRate = defaultThrottleMinRate
+	}
+	if t.minRate > t.maxRate {
+		t.minRate = t.maxRate
+	}
+	if t.decreaseFactor <= 0 || t.decreaseFactor >= 1 {
+		t.decreaseFactor = defaultThrottleDecreaseFactor
+	}
+	if t.increaseStep <= 0 {
+		t.increaseStep = defaultThrottleIncreaseStep
+	}
+	if t.defaultPause <= 0 {
+		t.defaultPause = defaultThrottlePause
+	}
+
+	return t
+}
+
+// Ready reports whether the provider is not paused.
+func (t *Throttle) Ready() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return !t.now().Before(t.pausedUntil)
+}
+
+// PausedUntil returns when the current pause ends, or the zero time when the
+// provider is not paused.
+func (t *Throttle) PausedUntil() time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.now().Before(t.pausedUntil) {
+		return t.pausedUntil
+	}
+	return time.Time{}
+}
+
+// wait blocks until the current rate allows the next send. It fails with a
+// ThrottledError while the provider is paused or when ctx is done first, so
+// the message is handed back instead of counted as a failed attempt.
+func (t *Throttle) wait(ctx context.Context) error {
+	t.mu.Lock()
+	now := t.now()
+	if now.Before(t.pausedUntil) {
+		retryAfter := t.pausedUntil.Sub(now)
+		t.mu.Unlock()
+		return &ThrottledError{RetryAfter: retryAfter, err: fmt.Errorf("provider %s is paused", t.provider)}
+	}
+
+	if t.rate == 0 {
+		t.mu.Unlock()
+		return nil
+	}
+
+	slot := t.nextSlot
+	if slot.Before(now) {
+		slot = now
+	}
+	t.nextSlot = slot.Add(time.Duration(float64(time.Second) / t.rate))
+	t.mu.Unlock()
+
+	delay := slot.Sub(now)
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return &ThrottledError{err: fmt.Errorf("provider %s: waiting for the send rate: %w", t.provider, ctx.Err())}
+	}
+}
+
+// throttled pauses the provider and cuts the send rate.
+func (t *Throttle) throttled(retryAfter time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if retryAfter <= 0 {
+		retryAfter = t.defaultPause
+	}
+
+	now := t.now()
+	if until := now.Add(retryAfter); until.After(t.pausedUntil) {
+		t.pausedUntil = until
+	}
+
+	if t.rate == 0 {
+		t.rate = t.maxRate
+	}
+	t.rate *= t.decreaseFactor
+	if t.rate < t.minRate {
+		t.rate = t.minRate
+	}
+	t.nextSlot = t.pausedUntil
+
+	t.logger.Warn("Provider is throttling, pausing sends",
+		zap.String("provider", t.provider),
+		zap.Duration("retryAfter", retryAfter),
+		zap.Float64("rate", t.rate))
+}
+
+// accepted raises the send rate after a send the provider accepted. Raising
+// it by step/rate per send adds about step messages per second every second.
+func (t *Throttle) accepted() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.rate == 0 {
+		return
+	}
+
+	t.rate += t.increaseStep / t.rate
+	if t.rate >= t.maxRate {
+		t.rate = 0
+		t.nextSlot = time.Time{}
+		t.logger.Info("Provider is no longer throttling, send rate limit lifted", zap.String("provider", t.provider))
+	}
+}
+
+// maxWait is the longest a send waits for its slot when queued sends are
+// ahead of it and the rate is at its minimum.
+func (t *Throttle) maxWait(queued int) time.Duration {
+	return time.Duration(float64(queued) * float64(time.Second) / t.minRate)
+}
+
+func (t *Throttle) Info() model.ThrottleInfo {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	info := model.ThrottleInfo{
+		Provider:  t.provider,
+		Throttled: t.rate != 0,
+		Rate:      t.rate,
+	}
+	if t.now().Before(t.pausedUntil) {
+		info.PausedUntil = t.pausedUntil
+	}
+	return info
+}
+
+// throttledSender paces a sender and pauses it when the provider throttles.
+type throttledSender struct {
+	Sender
+	throttle *Throttle
+}
+
+func (s *throttledSender) Send(ctx context.Context, msg model.Message) (string, error) {
+	if err := s.throttle.wait(ctx); err != nil {
+		return "", err
+	}
+
+	messageID, err := s.Sender.Send(ctx, msg)
+
+	var throttledErr *ThrottledError
+	switch {
+	case errors.As(err, &throttledErr):
+		s.throttle.throttled(throttledErr.RetryAfter)
+	case err == nil:
+		s.throttle.accepted()
+	}
+
+	return messageID, err
+}
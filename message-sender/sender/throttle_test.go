@@ -0,0 +1,118 @@
+package sender
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zaptest"
+
+	"message-sender/config"
+	"message-sender/model"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value    string
+		expected time.Duration
+	}{
+		{value: "", expected: 0},
+		{value: "30", expected: 30 * time.Second},
+		{value: "-1", expected: 0},
+		{value: "Mon, 01 Jan 2024 12:01:00 GMT", expected: time.Minute},
+		{value: "Mon, 01 Jan 2024 11:59:00 GMT", expected: 0},
+		{value: "soon", expected: 0},
+	}
+
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.value, now); got != tt.expected {
+			t.Errorf("Retry-After %q: expected %s, got %s", tt.value, tt.expected, got)
+		}
+	}
+}
+
+func TestThrottle_AdjustsRateAdditivelyAndMultiplicatively(t *testing.T) {
+	now := time.Now()
+	cfg := config.ThrottleConfig{MaxRate: 10, MinRate: 1, DecreaseFactor: 0.5, IncreaseStep: 4, DefaultPause: time.Second}
+	throttle := NewThrottle("primary", cfg, zaptest.NewLogger(t))
+	throttle.now = func() time.Time { return now }
+
+	if info := throttle.Info(); info.Throttled {
+		t.Fatalf("Expected no limit before the provider throttles, got %+v", info)
+	}
+
+	throttle.throttled(30 * time.Second)
+	if info := throttle.Info(); !info.Throttled || info.Rate != 5 || !info.PausedUntil.Equal(now.Add(30*time.Second)) {
+		t.Fatalf("Expected a rate of 5 paused for 30s, got %+v", info)
+	}
+	if throttle.Ready() {
+		t.Fatal("Expected the throttle to be paused")
+	}
+
+	var throttledErr *ThrottledError
+	if err := throttle.wait(context.Background()); !errors.As(err, &throttledErr) || throttledErr.RetryAfter != 30*time.Second {
+		t.Fatalf("Expected a ThrottledError with the rest of the pause, got %v", err)
+	}
+
+	// Without a Retry-After the default pause is used and the rate halves again
+	throttle.throttled(0)
+	throttle.throttled(0)
+	if rate := throttle.Info().Rate; rate != 1.25 {
+		t.Fatalf("Expected a rate of 1.25, got %v", rate)
+	}
+	throttle.throttled(0)
+	if rate := throttle.Info().Rate; rate != 1 {
+		t.Fatalf("Expected the rate to stop at the minimum, got %v", rate)
+	}
+
+	now = now.Add(time.Minute)
+	throttle.accepted()
+	if rate := throttle.Info().Rate; rate != 5 {
+		t.Fatalf("Expected an accepted send to raise the rate by step/rate, got %v", rate)
+	}
+
+	for i := 0; i < 10 && throttle.Info().Throttled; i++ {
+		throttle.accepted()
+	}
+	if info := throttle.Info(); info.Throttled || !info.PausedUntil.IsZero() {
+		t.Errorf("Expected the limit to be lifted at the maximum rate, got %+v", info)
+	}
+}
+
+func TestWebhookSender_RecognizesThrottling(t *testing.T) {
+	retryAfter := "7"
+	status := http.StatusTooManyRequests
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if retryAfter != "" {
+			w.Header().Set("Retry-After", retryAfter)
+		}
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	webhook := NewWebhookSender("webhook", config.WebhookConfig{URL: server.URL})
+
+	var throttledErr *ThrottledError
+	_, err := webhook.Send(context.Background(), model.Message{ID: 1})
+	if !errors.As(err, &throttledErr) || throttledErr.RetryAfter != 7*time.Second {
+		t.Fatalf("Expected a ThrottledError with a 7s Retry-After, got %v", err)
+	}
+	if errors.Is(err, ErrUnavailable) {
+		t.Error("Expected throttling not to count as unavailable")
+	}
+
+	status = http.StatusServiceUnavailable
+	if _, err := webhook.Send(context.Background(), model.Message{ID: 1}); !errors.Is(err, ErrThrottled) {
+		t.Errorf("Expected 503 with Retry-After to be throttling, got %v", err)
+	}
+
+	retryAfter = ""
+	if _, err := webhook.Send(context.Background(), model.Message{ID: 1}); errors.Is(err, ErrThrottled) || !errors.Is(err, ErrUnavailable) {
+		t.Errorf("Expected 503 without Retry-After to be unavailable, got %v", err)
+	}
+}
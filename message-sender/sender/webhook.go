@@ -0,0 +1,71 @@
+package sender
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"message-sender/config"
+	"message-sender/model"
+)
+
+// WebhookSender delivers messages by posting them to an HTTP webhook.
+type WebhookSender struct {
+	name       string
+	cfg        config.WebhookConfig
+	httpClient *http.Client
+}
+
+func NewWebhookSender(name string, cfg config.WebhookConfig) *WebhookSender {
+	return &WebhookSender{
+		name: name,
+		cfg:  cfg,
+		httpClient: &http.Client{
+			Timeout: cfg.Timeout,
+		},
+	}
+}
+
+func (w *WebhookSender) Name() string {
+	return w.name
+}
+
+func (w *WebhookSender) Send(ctx context.Context, msg model.Message) (string, error) {
+	if msg.SenderID == "" {
+		msg.SenderID = w.cfg.SenderID
+	}
+
+	jsonData, err := renderPayload(w.cfg.PayloadTemplate, msg)
+	if err != nil {
+		return "", fmt.Errorf("failed to build message payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.cfg.URL, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return "", fmt.Errorf("failed to create webhook request: %w", err)
+	}
+
+	if err := setWebhookHeaders(req, w.cfg); err != nil {
+		return "", err
+	}
+
+	resp, err := w.httpClient.Do(req)
+	if err != nil {
+		err = fmt.Errorf("failed to send webhook request: %w", err)
+		if ctx.Err() != nil {
+			return "", err
+		}
+		// Timeouts and connection errors are the provider's, not ours
+		return "", &unavailableError{err: err}
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxWebhookResponseSize))
+	if err != nil {
+		return "", fmt.Errorf("failed to read webhook response: %w", err)
+	}
+
+	return w.parseResponse(msg, resp, body)
+}
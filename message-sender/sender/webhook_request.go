@@ -0,0 +1,95 @@
+package sender
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"message-sender/config"
+	"message-sender/model"
+)
+
+// defaultPayloadTemplate is the request body used when no template is
+// configured.
+const defaultPayloadTemplate = `{"content":"{{content}}","recipient":"{{recipient}}"}`
+
+// renderPayload builds the webhook request body from a JSON template. A
+// string value that is exactly one placeholder is replaced by the field value
+// with its JSON type; placeholders inside longer strings are interpolated.
+// Keys, static values and nesting are kept as they are in the template.
+func renderPayload(template string, msg model.Message) ([]byte, error) {
+	if template == "" {
+		template = defaultPayloadTemplate
+	}
+
+	var node interface{}
+	if err := json.Unmarshal([]byte(template), &node); err != nil {
+		return nil, fmt.Errorf("failed to parse payload template: %w", err)
+	}
+
+	fields := map[string]interface{}{
+		"id":        msg.ID,
+		"content":   msg.Content,
+		"recipient": msg.Recipient,
+		"senderId":  msg.SenderID,
+	}
+
+	return json.Marshal(renderNode(node, fields))
+}
+
+func renderNode(node interface{}, fields map[string]interface{}) interface{} {
+	switch value := node.(type) {
+	case map[string]interface{}:
+		for key, child := range value {
+			value[key] = renderNode(child, fields)
+		}
+		return value
+	case []interface{}:
+		for i, child := range value {
+			value[i] = renderNode(child, fields)
+		}
+		return value
+	case string:
+		return renderString(value, fields)
+	default:
+		return value
+	}
+}
+
+func renderString(value string, fields map[string]interface{}) interface{} {
+	pairs := make([]string, 0, 2*len(fields))
+	for name, field := range fields {
+		placeholder := "{{" + name + "}}"
+		if value == placeholder {
+			return field
+		}
+		pairs = append(pairs, placeholder, fmt.Sprint(field))
+	}
+
+	// A single pass so that placeholders inside substituted values, for
+	// example in message content, are left alone.
+	return strings.NewReplacer(pairs...).Replace(value)
+}
+
+// setWebhookHeaders applies the configured headers, reading secret values from
+// the environment or files on every request so rotated secrets are picked up.
+func setWebhookHeaders(req *http.Request, cfg config.WebhookConfig) error {
+	req.Header.Set("Content-Type", "application/json")
+
+	for _, entry := range cfg.Headers {
+		name, source, err := config.ParseHeader(entry)
+		if err != nil {
+			return err
+		}
+
+		value, err := config.ResolveSecret(source)
+		if err != nil {
+			return fmt.Errorf("failed to resolve header %s: %w", name, err)
+		}
+
+		req.Header.Set(name, value)
+	}
+
+	return nil
+}
@@ -0,0 +1,103 @@
+package sender
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"message-sender/config"
+	"message-sender/model"
+)
+
+func TestRenderPayload(t *testing.T) {
+	msg := model.Message{ID: 7, Content: "Your code is {{recipient}}", Recipient: "+905500000000"}
+
+	tests := []struct {
+		name     string
+		template string
+		expected string
+	}{
+		{
+			name:     "default template",
+			template: "",
+			expected: `{"content":"Your code is {{recipient}}","recipient":"+905500000000"}`,
+		},
+		{
+			name:     "renamed fields",
+			template: `{"to":"{{recipient}}","content":"{{content}}"}`,
+			expected: `{"to":"+905500000000","content":"Your code is {{recipient}}"}`,
+		},
+		{
+			name:     "static and nested fields",
+			template: `{"channel":"sms","priority":1,"message":{"ref":"{{id}}","body":{"text":"{{content}}"}},"targets":["{{recipient}}"]}`,
+			expected: `{"channel":"sms","priority":1,"message":{"ref":7,"body":{"text":"Your code is {{recipient}}"}},"targets":["+905500000000"]}`,
+		},
+		{
+			name:     "interpolated string",
+			template: `{"reference":"msg-{{id}}"}`,
+			expected: `{"reference":"msg-7"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload, err := renderPayload(tt.template, msg)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			var got, expected interface{}
+			_ = json.Unmarshal(payload, &got)
+			_ = json.Unmarshal([]byte(tt.expected), &expected)
+			if !reflect.DeepEqual(got, expected) {
+				t.Errorf("Expected %s, got %s", tt.expected, payload)
+			}
+		})
+	}
+
+	if _, err := renderPayload(`{"to":`, msg); err == nil {
+		t.Error("Expected an error for an invalid template")
+	}
+}
+
+func TestSetWebhookHeaders(t *testing.T) {
+	t.Setenv("TEST_WEBHOOK_AUTH_KEY", "env-secret")
+
+	secretFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(secretFile, []byte("file-secret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.WebhookConfig{
+		Headers: []string{
+			"x-ins-auth-key=env:TEST_WEBHOOK_AUTH_KEY",
+			"Authorization=file:" + secretFile,
+			"X-Client=message-sender",
+		},
+	}
+
+	req, _ := http.NewRequest(http.MethodPost, "http://localhost", nil)
+	if err := setWebhookHeaders(req, cfg); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := map[string]string{
+		"Content-Type":   "application/json",
+		"x-ins-auth-key": "env-secret",
+		"Authorization":  "file-secret",
+		"X-Client":       "message-sender",
+	}
+	for name, value := range expected {
+		if got := req.Header.Get(name); got != value {
+			t.Errorf("Expected header %s=%q, got %q", name, value, got)
+		}
+	}
+
+	cfg.Headers = []string{"x-ins-auth-key=env:TEST_WEBHOOK_MISSING_KEY"}
+	if err := setWebhookHeaders(req, cfg); err == nil {
+		t.Error("Expected an error for an unset environment variable")
+	}
+}
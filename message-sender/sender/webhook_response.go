@@ -0,0 +1,124 @@
+package sender
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"message-sender/model"
+)
+
+// maxWebhookResponseSize caps how much of a response body is read, so a
+// misbehaving provider cannot make the processor buffer arbitrary amounts.
+const maxWebhookResponseSize = 1 << 20
+
+var ErrMissingMessageID = errors.New("webhook response has no message ID")
+
+// parseResponse applies the response contract in WebhookConfig: which status
+// codes count as success, where the provider's message ID and error reason
+// live in the JSON body, and what to do when the ID is missing.
+func (w *WebhookSender) parseResponse(msg model.Message, resp *http.Response, body []byte) (string, error) {
+	cfg := w.cfg
+
+	if !isSuccessStatus(resp.StatusCode, cfg.SuccessStatusCodes) {
+		err := fmt.Errorf("webhook returned non-success status: %d", resp.StatusCode)
+		if reason, ok := lookupJSONPath(body, cfg.ErrorPath); ok && reason != "" {
+			err = fmt.Errorf("webhook returned non-success status: %d: %s", resp.StatusCode, reason)
+		}
+
+		if isThrottled(resp) {
+			return "", &ThrottledError{RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()), err: err}
+		}
+		if resp.StatusCode >= http.StatusInternalServerError {
+			return "", &unavailableError{err: err}
+		}
+		return "", err
+	}
+
+	if messageID, ok := lookupJSONPath(body, cfg.MessageIDPath); ok && messageID != "" {
+		return messageID, nil
+	}
+
+	if cfg.StrictMessageID {
+		return "", fmt.Errorf("%w at %q", ErrMissingMessageID, cfg.MessageIDPath)
+	}
+
+	if !cfg.FallbackMessageID {
+		return "", nil
+	}
+
+	requestID := resp.Header.Get("X-Request-Id")
+	if requestID != "" {
+		return requestID, nil
+	}
+
+	uniqueID := fmt.Sprintf("webhook-%d-%d", msg.ID, time.Now().UnixNano())
+	return uniqueID, nil
+}
+
+// isSuccessStatus reports whether code is one of the configured success codes,
+// or any 2xx code when none are configured.
+func isSuccessStatus(code int, successCodes []int) bool {
+	if len(successCodes) == 0 {
+		return code >= 200 && code < 300
+	}
+
+	for _, successCode := range successCodes {
+		if code == successCode {
+			return true
+		}
+	}
+	return false
+}
+
+// lookupJSONPath returns the scalar at a dot-separated path such as
+// "messageId" or "data.items.0.id" in a JSON document. Numeric segments index
+// into arrays.
+func lookupJSONPath(body []byte, path string) (string, bool) {
+	if path == "" || len(body) == 0 {
+		return "", false
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var node interface{}
+	if err := decoder.Decode(&node); err != nil {
+		return "", false
+	}
+
+	for _, segment := range strings.Split(path, ".") {
+		switch current := node.(type) {
+		case map[string]interface{}:
+			next, ok := current[segment]
+			if !ok {
+				return "", false
+			}
+			node = next
+		case []interface{}:
+			index, err := strconv.Atoi(segment)
+			if err != nil || index < 0 || index >= len(current) {
+				return "", false
+			}
+			node = current[index]
+		default:
+			return "", false
+		}
+	}
+
+	switch value := node.(type) {
+	case string:
+		return value, true
+	case json.Number:
+		return value.String(), true
+	case bool:
+		return strconv.FormatBool(value), true
+	default:
+		return "", false
+	}
+}
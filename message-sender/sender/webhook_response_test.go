@@ -0,0 +1,129 @@
+package sender
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"message-sender/config"
+	"message-sender/model"
+)
+
+func TestLookupJSONPath(t *testing.T) {
+	body := []byte(`{"message":"Accepted","messageId":"abc-123","data":{"items":[{"id":42}],"ok":true}}`)
+
+	tests := []struct {
+		path     string
+		expected string
+		found    bool
+	}{
+		{path: "messageId", expected: "abc-123", found: true},
+		{path: "data.items.0.id", expected: "42", found: true},
+		{path: "data.ok", expected: "true", found: true},
+		{path: "data.items.1.id", found: false},
+		{path: "data.missing", found: false},
+		{path: "data", found: false},
+		{path: "", found: false},
+	}
+
+	for _, tt := range tests {
+		value, found := lookupJSONPath(body, tt.path)
+		if found != tt.found || value != tt.expected {
+			t.Errorf("path %q: expected (%q, %v), got (%q, %v)", tt.path, tt.expected, tt.found, value, found)
+		}
+	}
+
+	if _, found := lookupJSONPath([]byte("not json"), "messageId"); found {
+		t.Error("Expected no value from an invalid JSON body")
+	}
+}
+
+func TestWebhookSender_ParseResponse(t *testing.T) {
+	tests := []struct {
+		name       string
+		webhook    config.WebhookConfig
+		statusCode int
+		header     string
+		body       string
+		expectedID string
+		expectErr  string
+	}{
+		{
+			name:       "message ID from body",
+			webhook:    config.WebhookConfig{SuccessStatusCodes: []int{202}, MessageIDPath: "messageId"},
+			statusCode: http.StatusAccepted,
+			body:       `{"message":"Accepted","messageId":"67f2f8a8-ea58-4ed0-a6f9-ff217df4d849"}`,
+			expectedID: "67f2f8a8-ea58-4ed0-a6f9-ff217df4d849",
+		},
+		{
+			name:       "status code outside the configured set",
+			webhook:    config.WebhookConfig{SuccessStatusCodes: []int{202}, MessageIDPath: "messageId", ErrorPath: "message"},
+			statusCode: http.StatusOK,
+			body:       `{"message":"Accepted","messageId":"abc"}`,
+			expectErr:  "non-success status: 200",
+		},
+		{
+			name:       "error reason from body",
+			webhook:    config.WebhookConfig{ErrorPath: "error.reason"},
+			statusCode: http.StatusBadRequest,
+			body:       `{"error":{"reason":"invalid recipient"}}`,
+			expectErr:  "non-success status: 400: invalid recipient",
+		},
+		{
+			name:       "strict mode rejects missing ID",
+			webhook:    config.WebhookConfig{MessageIDPath: "messageId", StrictMessageID: true, FallbackMessageID: true},
+			statusCode: http.StatusAccepted,
+			header:     "request-id",
+			body:       `{"message":"Accepted"}`,
+			expectErr:  ErrMissingMessageID.Error(),
+		},
+		{
+			name:       "fallback to request ID header",
+			webhook:    config.WebhookConfig{MessageIDPath: "messageId", FallbackMessageID: true},
+			statusCode: http.StatusAccepted,
+			header:     "request-id",
+			body:       `{"message":"Accepted"}`,
+			expectedID: "request-id",
+		},
+		{
+			name:       "no ID without fallback",
+			webhook:    config.WebhookConfig{MessageIDPath: "messageId"},
+			statusCode: http.StatusAccepted,
+			header:     "request-id",
+			body:       `{"message":"Accepted"}`,
+			expectedID: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			webhook := NewWebhookSender("webhook", tt.webhook)
+
+			resp := &http.Response{StatusCode: tt.statusCode, Header: http.Header{}}
+			if tt.header != "" {
+				resp.Header.Set("X-Request-Id", tt.header)
+			}
+
+			messageID, err := webhook.parseResponse(model.Message{ID: 1}, resp, []byte(tt.body))
+			if tt.expectErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectErr) {
+					t.Fatalf("Expected error containing %q, got %v", tt.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if messageID != tt.expectedID {
+				t.Errorf("Expected message ID %q, got %q", tt.expectedID, messageID)
+			}
+		})
+	}
+
+	webhook := NewWebhookSender("webhook", config.WebhookConfig{MessageIDPath: "messageId", StrictMessageID: true})
+	_, err := webhook.parseResponse(model.Message{ID: 1}, &http.Response{StatusCode: http.StatusAccepted}, nil)
+	if !errors.Is(err, ErrMissingMessageID) {
+		t.Errorf("Expected ErrMissingMessageID, got %v", err)
+	}
+}
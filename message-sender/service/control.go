@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"message-sender/model"
+)
+
+// controlResyncInterval bounds how long a replica can run out of step with
+// the cluster status when a pub/sub command is lost, for example while the
+// subscription is reconnecting.
+const controlResyncInterval = 30 * time.Second
+
+// Run drives the processor for the lifetime of the process. It applies the
+// configured startup mode, then keeps the local loop in line with the
+// cluster-wide service status whenever a control command is broadcast and
+// periodically as a safety net. When ctx is done the local loop is stopped
+// and Run returns once it has exited.
+func (s *MessageProcessor) Run(ctx context.Context) error {
+	commands, err := s.statusRepo.SubscribeControl(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to control commands: %w", err)
+	}
+	defer s.shutdownLoop()
+
+	// In stopped mode the instance ignores the stored status until it is
+	// told otherwise by a control command.
+	following := true
+
+	switch mode := s.startupMode(); mode {
+	case model.StartupStart:
+		if err := s.StartService(ctx); err != nil {
+			return fmt.Errorf("failed to start service on boot: %w", err)
+		}
+	case model.StartupRestore:
+		s.syncWithStatus(ctx)
+	case model.StartupStopped:
+		following = false
+		s.logger.Info("Message sending service stays stopped until started")
+	default:
+		return fmt.Errorf("unknown startup mode: %s", mode)
+	}
+
+	ticker := time.NewTicker(controlResyncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case action, ok := <-commands:
+			if !ok {
+				return nil
+			}
+			s.logger.Info("Received control command", zap.String("action", string(action)))
+			following = true
+			s.syncWithStatus(ctx)
+		case <-ticker.C:
+			if following {
+				s.syncWithStatus(ctx)
+			}
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (s *MessageProcessor) startupMode() model.StartupMode {
+	if s.cfg.Message.StartupMode == "" {
+		return model.StartupRestore
+	}
+	return model.StartupMode(s.cfg.Message.StartupMode)
+}
+
+// syncWithStatus starts or stops the local loop to match the status stored
+// in Redis. The stored status wins over the command itself so that commands
+// delivered out of order still settle on the latest state.
+func (s *MessageProcessor) syncWithStatus(ctx context.Context) {
+	status, err := s.statusRepo.GetServiceStatus(ctx)
+	if err != nil {
+		s.logger.Error("Failed to get service status", zap.Error(err))
+		return
+	}
+
+	s.processingMux.Lock()
+	defer s.processingMux.Unlock()
+
+	switch status {
+	case model.StatusRunning:
+		s.startLoop()
+	case model.StatusStopped:
+		s.stopLoop()
+	}
+}
+
+// shutdownLoop stops the local loop without touching the cluster-wide status
+// and waits for it to exit.
+func (s *MessageProcessor) shutdownLoop() {
+	s.processingMux.Lock()
+	loopDone := s.stopLoop()
+	s.processingMux.Unlock()
+
+	<-loopDone
+}
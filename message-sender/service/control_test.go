@@ -0,0 +1,228 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zaptest"
+
+	"message-sender/config"
+	"message-sender/model"
+)
+
+func waitForLoop(t *testing.T, processor *MessageProcessor, running bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for processor.isLoopRunning() != running && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if processor.isLoopRunning() != running {
+		t.Fatalf("Expected loop running=%v", running)
+	}
+}
+
+func runProcessor(t *testing.T, processor *MessageProcessor) (context.CancelFunc, <-chan error) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- processor.Run(ctx)
+	}()
+
+	return cancel, done
+}
+
+func TestMessageProcessor_Run_FollowsControlCommands(t *testing.T) {
+	mockStatusRepo := &MockStatusRepository{
+		status:   model.StatusRunning,
+		commands: make(chan model.ActionType),
+	}
+	cfg := &config.Config{
+		Message: config.MessageConfig{
+			ProcessInterval: time.Hour,
+			StartupMode:     string(model.StartupRestore),
+		},
+	}
+
+	processor := NewMessageProcessor(&MockRepository{}, mockStatusRepo, &MockCacheRepository{}, newTestSenders(t, cfg), zaptest.NewLogger(t), cfg)
+	cancel, done := runProcessor(t, processor)
+	ctx := context.Background()
+
+	// The status is restored on boot
+	waitForLoop(t, processor, true)
+
+	// Another replica stopped the service
+	_ = mockStatusRepo.SetServiceStatus(ctx, model.StatusStopped)
+	mockStatusRepo.commands <- model.ActionStop
+	waitForLoop(t, processor, false)
+
+	// Another replica started the service again
+	_ = mockStatusRepo.SetServiceStatus(ctx, model.StatusRunning)
+	mockStatusRepo.commands <- model.ActionStart
+	waitForLoop(t, processor, true)
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if processor.isLoopRunning() {
+		t.Error("Expected loop to be stopped on shutdown")
+	}
+	if status, _ := mockStatusRepo.GetServiceStatus(ctx); status != model.StatusRunning {
+		t.Errorf("Expected shutdown to leave cluster status %s, got %s", model.StatusRunning, status)
+	}
+}
+
+func TestMessageProcessor_Run_StartupModes(t *testing.T) {
+	tests := []struct {
+		mode           model.StartupMode
+		storedStatus   model.ServiceStatus
+		expectRunning  bool
+		expectedStatus model.ServiceStatus
+	}{
+		{mode: model.StartupStart, storedStatus: model.StatusStopped, expectRunning: true, expectedStatus: model.StatusRunning},
+		{mode: model.StartupRestore, storedStatus: model.StatusRunning, expectRunning: true, expectedStatus: model.StatusRunning},
+		{mode: model.StartupRestore, storedStatus: model.StatusStopped, expectRunning: false, expectedStatus: model.StatusStopped},
+		{mode: model.StartupStopped, storedStatus: model.StatusRunning, expectRunning: false, expectedStatus: model.StatusRunning},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.mode)+"/"+string(tt.storedStatus), func(t *testing.T) {
+			mockStatusRepo := &MockStatusRepository{status: tt.storedStatus}
+			cfg := &config.Config{
+				Message: config.MessageConfig{
+					ProcessInterval: time.Hour,
+					StartupMode:     string(tt.mode),
+				},
+			}
+
+			processor := NewMessageProcessor(&MockRepository{}, mockStatusRepo, &MockCacheRepository{}, newTestSenders(t, cfg), zaptest.NewLogger(t), cfg)
+			cancel, done := runProcessor(t, processor)
+			defer func() {
+				cancel()
+				<-done
+			}()
+
+			waitForLoop(t, processor, tt.expectRunning)
+			if status, _ := mockStatusRepo.GetServiceStatus(context.Background()); status != tt.expectedStatus {
+				t.Errorf("Expected status %s, got %s", tt.expectedStatus, status)
+			}
+		})
+	}
+}
+
+func TestMessageProcessor_Run_StoppedModeStartsOnCommand(t *testing.T) {
+	mockStatusRepo := &MockStatusRepository{
+		status:   model.StatusRunning,
+		commands: make(chan model.ActionType),
+	}
+	cfg := &config.Config{
+		Message: config.MessageConfig{
+			ProcessInterval: time.Hour,
+			StartupMode:     string(model.StartupStopped),
+		},
+	}
+
+	processor := NewMessageProcessor(&MockRepository{}, mockStatusRepo, &MockCacheRepository{}, newTestSenders(t, cfg), zaptest.NewLogger(t), cfg)
+	cancel, done := runProcessor(t, processor)
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	mockStatusRepo.commands <- model.ActionStart
+	waitForLoop(t, processor, true)
+}
+
+func TestMessageProcessor_ShutdownDrainsInFlightSend(t *testing.T) {
+	received := make(chan struct{}, 1)
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- struct{}{}
+		time.Sleep(100 * time.Millisecond)
+		w.Header().Set("X-Request-Id", "provider-id")
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer webhook.Close()
+
+	mockRepo := &MockRepository{
+		messages: []model.Message{
+			{ID: 1, Content: "first", Recipient: "+905500000000"},
+			{ID: 2, Content: "second", Recipient: "+905500000001"},
+		},
+	}
+	cfg := &config.Config{
+		Message: config.MessageConfig{
+			ProcessInterval: time.Hour,
+			DrainTimeout:    time.Second,
+		},
+		Webhook: config.WebhookConfig{URL: webhook.URL, Timeout: time.Second},
+	}
+
+	processor := NewMessageProcessor(mockRepo, &MockStatusRepository{}, &MockCacheRepository{}, newTestSenders(t, cfg), zaptest.NewLogger(t), cfg)
+
+	processor.processingMux.Lock()
+	processor.startLoop()
+	processor.processingMux.Unlock()
+
+	<-received
+	processor.shutdownLoop()
+
+	expected := []model.MessageStatus{model.MessageSent, model.MessageQueued}
+	if len(mockRepo.transitions) != len(expected) {
+		t.Fatalf("Expected transitions %v, got %v", expected, mockRepo.transitions)
+	}
+	for i, status := range expected {
+		if mockRepo.transitions[i] != status {
+			t.Errorf("Expected transition %d to be %s, got %s", i, status, mockRepo.transitions[i])
+		}
+	}
+}
+
+func TestMessageProcessor_ShutdownCancelsSendsAfterDrainDeadline(t *testing.T) {
+	received := make(chan struct{}, 1)
+	release := make(chan struct{})
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- struct{}{}
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer webhook.Close()
+	defer close(release)
+
+	mockRepo := &MockRepository{
+		messages: []model.Message{{ID: 1, Content: "slow", Recipient: "+905500000000"}},
+	}
+	cfg := &config.Config{
+		Message: config.MessageConfig{
+			ProcessInterval: time.Hour,
+			DrainTimeout:    50 * time.Millisecond,
+		},
+		Webhook: config.WebhookConfig{URL: webhook.URL, Timeout: 10 * time.Second},
+	}
+
+	processor := NewMessageProcessor(mockRepo, &MockStatusRepository{}, &MockCacheRepository{}, newTestSenders(t, cfg), zaptest.NewLogger(t), cfg)
+
+	processor.processingMux.Lock()
+	processor.startLoop()
+	processor.processingMux.Unlock()
+
+	<-received
+	start := time.Now()
+	processor.shutdownLoop()
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected shutdown to end shortly after the drain deadline, took %s", elapsed)
+	}
+	if !mockRepo.markAsFailedCalled {
+		t.Error("Expected the cancelled send to be recorded as a failed attempt")
+	}
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"message-sender/config"
+	"message-sender/model"
+	"message-sender/sender"
+)
+
+// delivery is the outcome of sending one message through the providers.
+type delivery struct {
+	provider       string
+	senderID       string
+	messageID      string
+	failedOverFrom []string
+}
+
+// deliver sends msg through the provider candidates in order, starting with
+// the provider chosen by the routing rules. With failover enabled, a provider
+// that is unavailable (5xx or timeout) or throttling is followed by the next
+// candidate within the same attempt; any other error ends the attempt.
+func (s *MessageProcessor) deliver(ctx context.Context, msg model.Message) (delivery, error) {
+	route := s.route(msg.Recipient, msg.Tags)
+
+	candidates := s.senders.Candidates(route.Provider)
+	if len(candidates) == 0 {
+		return delivery{provider: route.Provider}, sender.ErrNoProviderReady
+	}
+	if !s.cfg.Sender.Failover {
+		candidates = candidates[:1]
+	}
+
+	var result delivery
+	for i, provider := range candidates {
+		// The routed sender ID belongs to the routed provider; the others use
+		// their own default.
+		attempt := msg
+		attempt.SenderID = ""
+		if provider.Name() == route.Provider {
+			attempt.SenderID = route.SenderID
+		}
+
+		result.provider = provider.Name()
+		result.senderID = attempt.SenderID
+
+		messageID, err := provider.Send(ctx, attempt)
+		if err == nil {
+			result.messageID = messageID
+			return result, nil
+		}
+
+		retryable := errors.Is(err, sender.ErrUnavailable) || errors.Is(err, sender.ErrThrottled)
+		if !retryable || i == len(candidates)-1 || ctx.Err() != nil {
+			return result, err
+		}
+
+		s.logger.Warn("Provider unavailable, failing over",
+			zap.Error(err),
+			zap.Uint("messageID", msg.ID),
+			zap.String("provider", provider.Name()),
+			zap.String("nextProvider", candidates[i+1].Name()))
+		result.failedOverFrom = append(result.failedOverFrom, provider.Name())
+	}
+
+	return result, nil
+}
+
+// isDeferral reports whether err means the message could not be sent right
+// now, rather than that the attempt failed, so its claim should be handed back.
+func isDeferral(err error) bool {
+	return errors.Is(err, sender.ErrNoProviderReady) ||
+		errors.Is(err, sender.ErrCircuitOpen) ||
+		errors.Is(err, sender.ErrThrottled)
+}
+
+// isFinal reports whether err means the provider accepted the message but the
+// outcome cannot be recorded, so another attempt would send it twice.
+func isFinal(err error) bool {
+	return errors.Is(err, sender.ErrMissingMessageID)
+}
+
+func (s *MessageProcessor) route(recipient string, tags []string) sender.Route {
+	if s.router == nil {
+		return sender.Route{}
+	}
+	return s.router.Resolve(recipient, tags)
+}
+
+func (s *MessageProcessor) ResolveRoute(recipient string, tags []string) (*model.RouteResolution, error) {
+	if !model.IsValidRecipient(recipient) {
+		return nil, ErrInvalidRecipient
+	}
+
+	resolution := &model.RouteResolution{Recipient: recipient, Tags: tags}
+
+	route := s.route(recipient, tags)
+	if route.Rule == nil {
+		resolution.Provider = s.senders.Default().Name()
+		resolution.Reason = "no rule matched, using the default provider"
+		if s.cfg.Sender.Strategy == config.StrategyWeighted {
+			resolution.Provider = ""
+			resolution.Reason = "no rule matched, the provider is picked by weight"
+		}
+		return resolution, nil
+	}
+
+	resolution.Matched = true
+	resolution.Rule = route.Rule
+	resolution.Provider = route.Provider
+	resolution.SenderID = route.SenderID
+	resolution.Reason = fmt.Sprintf("longest matching prefix %s", route.Rule.Prefix)
+	if len(route.Rule.Tags) > 0 {
+		resolution.Reason += fmt.Sprintf(" with tags %s", strings.Join(route.Rule.Tags, ","))
+	}
+
+	return resolution, nil
+}
@@ -0,0 +1,457 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zaptest"
+
+	"message-sender/config"
+	"message-sender/model"
+	"message-sender/sender"
+)
+
+func TestMessageProcessor_Deliver_FailsOverToNextProvider(t *testing.T) {
+	tests := []struct {
+		name             string
+		primaryStatus    int
+		failover         bool
+		expectedStatus   model.MessageStatus
+		expectedProvider string
+		expectedFailover []string
+		backupCalled     bool
+	}{
+		{
+			name:             "unavailable provider fails over",
+			primaryStatus:    http.StatusServiceUnavailable,
+			failover:         true,
+			expectedStatus:   model.MessageSent,
+			expectedProvider: "backup",
+			expectedFailover: []string{"primary"},
+			backupCalled:     true,
+		},
+		{
+			name:             "rejected message does not fail over",
+			primaryStatus:    http.StatusBadRequest,
+			failover:         true,
+			expectedStatus:   model.MessageFailed,
+			expectedProvider: "primary",
+		},
+		{
+			name:             "failover disabled",
+			primaryStatus:    http.StatusServiceUnavailable,
+			failover:         false,
+			expectedStatus:   model.MessageFailed,
+			expectedProvider: "primary",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.primaryStatus)
+			}))
+			defer primary.Close()
+
+			backupCalled := false
+			backup := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				backupCalled = true
+				w.WriteHeader(http.StatusAccepted)
+				_, _ = w.Write([]byte(`{"messageId":"backup-id"}`))
+			}))
+			defer backup.Close()
+
+			senders, err := sender.NewRegistry("primary",
+				sender.NewWebhookSender("primary", config.WebhookConfig{URL: primary.URL, Timeout: time.Second}),
+				sender.NewWebhookSender("backup", config.WebhookConfig{URL: backup.URL, Timeout: time.Second, MessageIDPath: "messageId"}),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			mockRepo := &MockRepository{
+				messages: []model.Message{{ID: 1, Content: "hello", Recipient: "+905500000000"}},
+			}
+			cfg := &config.Config{
+				Message: config.MessageConfig{MaxAttempts: 3},
+				Sender:  config.SenderConfig{Failover: tt.failover},
+			}
+
+			processor := NewMessageProcessor(mockRepo, &MockStatusRepository{}, &MockCacheRepository{}, senders, zaptest.NewLogger(t), cfg)
+			processor.processMessages(context.Background(), context.Background())
+
+			if len(mockRepo.transitions) != 1 || mockRepo.transitions[0] != tt.expectedStatus {
+				t.Fatalf("Expected transition to %s, got %v", tt.expectedStatus, mockRepo.transitions)
+			}
+			if mockRepo.provider != tt.expectedProvider {
+				t.Errorf("Expected provider %s, got %s", tt.expectedProvider, mockRepo.provider)
+			}
+			if !reflect.DeepEqual(mockRepo.failedOverFrom, tt.expectedFailover) {
+				t.Errorf("Expected failover from %v, got %v", tt.expectedFailover, mockRepo.failedOverFrom)
+			}
+			if backupCalled != tt.backupCalled {
+				t.Errorf("Expected backup called %v, got %v", tt.backupCalled, backupCalled)
+			}
+		})
+	}
+}
+
+func TestMessageProcessor_Deliver_UsesRoutedProvider(t *testing.T) {
+	var payload map[string]interface{}
+	routed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer routed.Close()
+
+	template := `{"to":"{{recipient}}","from":"{{senderId}}"}`
+	senders, err := sender.NewRegistry("default",
+		sender.NewWebhookSender("default", config.WebhookConfig{URL: "http://127.0.0.1:0"}),
+		sender.NewWebhookSender("turkey", config.WebhookConfig{URL: routed.URL, PayloadTemplate: template}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	router, err := sender.NewRouter("", senders, zaptest.NewLogger(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := router.SetRules([]model.RoutingRule{{Prefix: "+90", Provider: "turkey", SenderID: "INSIDER"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	mockRepo := &MockRepository{
+		messages: []model.Message{{ID: 1, Content: "hello", Recipient: "+905500000000"}},
+	}
+	cfg := &config.Config{}
+
+	processor := NewMessageProcessor(mockRepo, &MockStatusRepository{}, &MockCacheRepository{}, senders, zaptest.NewLogger(t), cfg)
+	processor.UseRouter(router)
+	processor.processMessages(context.Background(), context.Background())
+
+	if mockRepo.provider != "turkey" {
+		t.Fatalf("Expected the routed provider, got %q", mockRepo.provider)
+	}
+	if payload["from"] != "INSIDER" || payload["to"] != "+905500000000" {
+		t.Errorf("Expected the routed sender ID in the payload, got %v", payload)
+	}
+
+	resolution, err := processor.ResolveRoute("+905500000000", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resolution.Matched || resolution.Provider != "turkey" || resolution.SenderID != "INSIDER" {
+		t.Errorf("Expected the +90 rule to match, got %+v", resolution)
+	}
+
+	resolution, err = processor.ResolveRoute("+15551234567", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resolution.Matched || resolution.Provider != "default" {
+		t.Errorf("Expected the default provider without a match, got %+v", resolution)
+	}
+
+	if _, err := processor.ResolveRoute("905500000000", nil); !errors.Is(err, ErrInvalidRecipient) {
+		t.Errorf("Expected ErrInvalidRecipient, got %v", err)
+	}
+}
+
+func TestMessageProcessor_ProcessMessages_SkipsWhileBreakerIsOpen(t *testing.T) {
+	calls := 0
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer webhook.Close()
+
+	mockRepo := &MockRepository{
+		messages: []model.Message{
+			{ID: 1, Content: "first", Recipient: "+905500000000"},
+			{ID: 2, Content: "second", Recipient: "+905500000000"},
+		},
+	}
+	cfg := &config.Config{
+		Message: config.MessageConfig{MaxAttempts: 3},
+		Webhook: config.WebhookConfig{URL: webhook.URL, Timeout: time.Second},
+		Cluster: config.ClusterConfig{InstanceID: "worker-1"},
+	}
+
+	senders := newTestSenders(t, cfg)
+	senders.UseBreakers(config.BreakerConfig{MinRequests: 1, WindowSize: 1, CoolDown: time.Hour}, zaptest.NewLogger(t))
+
+	processor := NewMessageProcessor(mockRepo, &MockStatusRepository{}, &MockCacheRepository{}, senders, zaptest.NewLogger(t), cfg)
+	processor.processMessages(context.Background(), context.Background())
+
+	// The first failure opens the breaker, the second message goes back to the queue
+	expected := []model.MessageStatus{model.MessageFailed, model.MessageQueued}
+	if !reflect.DeepEqual(mockRepo.transitions, expected) {
+		t.Fatalf("Expected transitions %v, got %v", expected, mockRepo.transitions)
+	}
+	if calls != 1 {
+		t.Errorf("Expected one webhook call, got %d", calls)
+	}
+
+	mockRepo.claimedBy = ""
+	processor.processMessages(context.Background(), context.Background())
+
+	if mockRepo.claimedBy != "" {
+		t.Error("Expected no messages to be claimed while the breaker is open")
+	}
+	if len(mockRepo.transitions) != len(expected) {
+		t.Errorf("Expected no further transitions, got %v", mockRepo.transitions)
+	}
+	if mockRepo.tickCount() != 2 || mockRepo.expirySweeps != 2 {
+		t.Errorf("Expected claims to be released and messages expired on every tick, got %d and %d", mockRepo.tickCount(), mockRepo.expirySweeps)
+	}
+
+	breakers := processor.GetBreakers()
+	if len(breakers) != 1 || breakers[0].State != model.BreakerOpen {
+		t.Errorf("Expected the breaker to be reported open, got %+v", breakers)
+	}
+}
+
+func TestMessageProcessor_ProcessMessages_DefersThrottledMessages(t *testing.T) {
+	calls := 0
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Retry-After", "30")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer webhook.Close()
+
+	mockRepo := &MockRepository{
+		messages: []model.Message{
+			{ID: 1, Content: "first", Recipient: "+905500000000"},
+			{ID: 2, Content: "second", Recipient: "+905500000001"},
+		},
+	}
+	cfg := &config.Config{
+		Message: config.MessageConfig{MaxAttempts: 3},
+		Webhook: config.WebhookConfig{URL: webhook.URL, Timeout: time.Second},
+		Cluster: config.ClusterConfig{InstanceID: "worker-1"},
+	}
+
+	senders := newTestSenders(t, cfg)
+	senders.UseThrottling(config.ThrottleConfig{}, zaptest.NewLogger(t))
+
+	processor := NewMessageProcessor(mockRepo, &MockStatusRepository{}, &MockCacheRepository{}, senders, zaptest.NewLogger(t), cfg)
+	processor.processMessages(context.Background(), context.Background())
+
+	// Neither message is a failed attempt, and the rest of the batch is not sent
+	expected := []model.MessageStatus{model.MessageQueued, model.MessageQueued}
+	if !reflect.DeepEqual(mockRepo.transitions, expected) {
+		t.Fatalf("Expected transitions %v, got %v", expected, mockRepo.transitions)
+	}
+	if calls != 1 {
+		t.Errorf("Expected one webhook call, got %d", calls)
+	}
+	if delay := time.Until(mockRepo.nextAttemptAt); delay < 29*time.Second || delay > 30*time.Second {
+		t.Errorf("Expected the messages to be deferred for the Retry-After, got %s", delay)
+	}
+
+	mockRepo.claimedBy = ""
+	processor.processMessages(context.Background(), context.Background())
+	if mockRepo.claimedBy != "" {
+		t.Error("Expected no messages to be claimed while the provider is paused")
+	}
+
+	details, err := processor.GetServiceDetails(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(details.Throttles) != 1 || !details.Throttles[0].Throttled || details.Throttles[0].PausedUntil.IsZero() {
+		t.Errorf("Expected the throttle to be reported, got %+v", details.Throttles)
+	}
+}
+
+func TestMessageProcessor_ProcessMessages_SkipsLostClaims(t *testing.T) {
+	calls := 0
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer webhook.Close()
+
+	mockRepo := &MockRepository{
+		messages: []model.Message{
+			{ID: 1, Content: "first", Recipient: "+905500000000"},
+			{ID: 2, Content: "second", Recipient: "+905500000001"},
+		},
+		lostClaims: map[uint]bool{1: true},
+	}
+	cfg := &config.Config{
+		Webhook: config.WebhookConfig{URL: webhook.URL, Timeout: time.Second},
+	}
+
+	processor := NewMessageProcessor(mockRepo, &MockStatusRepository{}, &MockCacheRepository{}, newTestSenders(t, cfg), zaptest.NewLogger(t), cfg)
+	processor.processMessages(context.Background(), context.Background())
+
+	// The message whose lease ran out belongs to another worker now
+	if calls != 1 || !reflect.DeepEqual(mockRepo.transitions, []model.MessageStatus{model.MessageSent}) {
+		t.Errorf("Expected only the held message to be sent, got %d calls and transitions %v", calls, mockRepo.transitions)
+	}
+}
+
+func TestMessageProcessor_ProcessMessages_HoldsBackRecipientAfterFailure(t *testing.T) {
+	calls := 0
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer webhook.Close()
+
+	mockRepo := &MockRepository{
+		messages: []model.Message{
+			{ID: 1, Content: "first", Recipient: "+905500000000"},
+			{ID: 2, Content: "second", Recipient: "+905500000000"},
+		},
+	}
+	cfg := &config.Config{
+		Message: config.MessageConfig{MaxAttempts: 3},
+		Webhook: config.WebhookConfig{URL: webhook.URL, Timeout: time.Second},
+	}
+
+	processor := NewMessageProcessor(mockRepo, &MockStatusRepository{}, &MockCacheRepository{}, newTestSenders(t, cfg), zaptest.NewLogger(t), cfg)
+	processor.processMessages(context.Background(), context.Background())
+
+	// The second message must not overtake the first one's retry
+	expected := []model.MessageStatus{model.MessageFailed, model.MessageQueued}
+	if calls != 1 || !reflect.DeepEqual(mockRepo.transitions, expected) {
+		t.Errorf("Expected only the first message to be sent, got %d calls and transitions %v", calls, mockRepo.transitions)
+	}
+}
+
+func TestMessageProcessor_ProcessMessages_DoesNotRetryMissingMessageID(t *testing.T) {
+	calls := 0
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer webhook.Close()
+
+	mockRepo := &MockRepository{
+		messages: []model.Message{
+			{ID: 1, Content: "first", Recipient: "+905500000000", Attempts: 1},
+		},
+	}
+	cfg := &config.Config{
+		Message: config.MessageConfig{MaxAttempts: 3},
+		Webhook: config.WebhookConfig{URL: webhook.URL, Timeout: time.Second, MessageIDPath: "id", StrictMessageID: true},
+	}
+
+	processor := NewMessageProcessor(mockRepo, &MockStatusRepository{}, &MockCacheRepository{}, newTestSenders(t, cfg), zaptest.NewLogger(t), cfg)
+	processor.processMessages(context.Background(), context.Background())
+
+	// The provider accepted the message, so another attempt would be a duplicate
+	expected := []model.MessageStatus{model.MessageDead}
+	if calls != 1 || !reflect.DeepEqual(mockRepo.transitions, expected) {
+		t.Fatalf("Expected one call and transitions %v, got %d calls and %v", expected, calls, mockRepo.transitions)
+	}
+	if !strings.Contains(mockRepo.lastError, "no message ID") {
+		t.Errorf("Expected the missing ID as the reason, got %q", mockRepo.lastError)
+	}
+}
+
+func TestMessageProcessor_ProcessMessages_KeepsOutcomeOfNewerClaim(t *testing.T) {
+	mockRepo := &MockRepository{
+		messages: []model.Message{
+			{ID: 1, Content: "first", Recipient: "+905500000000"},
+		},
+	}
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The lease runs out mid-send and another worker claims the message
+		mockRepo.mu.Lock()
+		mockRepo.claimedBy = "worker-2"
+		mockRepo.mu.Unlock()
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer webhook.Close()
+
+	cfg := &config.Config{
+		Message: config.MessageConfig{MaxAttempts: 3},
+		Webhook: config.WebhookConfig{URL: webhook.URL, Timeout: time.Second},
+		Cluster: config.ClusterConfig{InstanceID: "worker-1"},
+	}
+
+	processor := NewMessageProcessor(mockRepo, &MockStatusRepository{}, &MockCacheRepository{}, newTestSenders(t, cfg), zaptest.NewLogger(t), cfg)
+	processor.processMessages(context.Background(), context.Background())
+
+	if len(mockRepo.transitions) != 0 {
+		t.Errorf("Expected no transitions once the claim is lost, got %v", mockRepo.transitions)
+	}
+}
+
+func TestMessageProcessor_ValidateLease(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      config.Config
+		failover bool
+		valid    bool
+	}{
+		{
+			name:  "lease outlasts the send",
+			cfg:   config.Config{Cluster: config.ClusterConfig{LeaseDuration: time.Minute}},
+			valid: true,
+		},
+		{
+			name:     "failover adds every provider",
+			cfg:      config.Config{Cluster: config.ClusterConfig{LeaseDuration: 20 * time.Second}},
+			failover: true,
+		},
+		{
+			name: "global rate limit waits up to half the lease",
+			cfg: config.Config{
+				Cluster:   config.ClusterConfig{LeaseDuration: 20 * time.Second},
+				RateLimit: config.RateLimitConfig{GlobalRate: 10},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := tt.cfg
+			cfg.Message.Workers = 2
+			cfg.Sender = config.SenderConfig{
+				Failover: tt.failover,
+				Provider: map[string]config.ProviderConfig{
+					"primary": {WebhookConfig: config.WebhookConfig{Timeout: 10 * time.Second}},
+					"backup":  {WebhookConfig: config.WebhookConfig{Timeout: 10 * time.Second}},
+				},
+			}
+
+			senders, err := sender.NewRegistry("primary",
+				sender.NewWebhookSender("primary", cfg.Sender.Provider["primary"].WebhookConfig),
+				sender.NewWebhookSender("backup", cfg.Sender.Provider["backup"].WebhookConfig),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// At the minimum rate of one send a second, two workers wait up to 2s
+			senders.UseThrottling(config.ThrottleConfig{}, zaptest.NewLogger(t))
+
+			processor := NewMessageProcessor(&MockRepository{}, &MockStatusRepository{}, &MockCacheRepository{}, senders, zaptest.NewLogger(t), &cfg)
+			if err := processor.ValidateLease(); (err == nil) != tt.valid {
+				t.Errorf("Expected valid %v, got %v", tt.valid, err)
+			}
+		})
+	}
+
+	cfg := &config.Config{}
+	processor := NewMessageProcessor(&MockRepository{}, &MockStatusRepository{}, &MockCacheRepository{}, newTestSenders(t, cfg), zaptest.NewLogger(t), cfg)
+	if err := processor.ValidateLease(); err == nil {
+		t.Error("Expected a provider without a timeout to be rejected")
+	}
+}
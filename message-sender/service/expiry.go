@@ -0,0 +1,46 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"message-sender/model"
+)
+
+// expiresAt returns when msg stops being worth sending, or the zero time when
+// it never does. Messages stored without an expiry fall back to the TTL of
+// their tags or priority, counted from when they became due.
+func (s *MessageProcessor) expiresAt(msg model.Message) time.Time {
+	if !msg.ExpiresAt.IsZero() {
+		return msg.ExpiresAt
+	}
+
+	ttl := s.cfg.Expiry.TTL(string(msg.Priority), msg.Tags)
+	if ttl <= 0 || msg.CreatedAt.IsZero() {
+		return time.Time{}
+	}
+
+	due := msg.CreatedAt
+	if msg.SendAt.After(due) {
+		due = msg.SendAt
+	}
+	return due.Add(ttl)
+}
+
+// expireMessage moves a claimed message that expired before it could be sent
+// to the expired state.
+func (s *MessageProcessor) expireMessage(ctx context.Context, msg model.Message, expiresAt time.Time) {
+	change := model.StatusChange{Reason: fmt.Sprintf("expired at %s before it was sent", expiresAt.Format(time.RFC3339))}
+	if err := s.transitionClaimed(ctx, msg.ID, model.MessageExpired, change); err != nil {
+		s.logger.Error("Failed to expire message", zap.Error(err), zap.Uint("messageID", msg.ID))
+		return
+	}
+
+	s.logger.Warn("Message expired before it was sent",
+		zap.Uint("messageID", msg.ID),
+		zap.String("priority", string(msg.Priority)),
+		zap.Time("expiresAt", expiresAt))
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zaptest"
+
+	"message-sender/config"
+	"message-sender/model"
+)
+
+func TestMessageProcessor_ProcessMessages_ExpiresStaleMessages(t *testing.T) {
+	calls := 0
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("X-Request-Id", "provider-id")
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer webhook.Close()
+
+	now := time.Now()
+	mockRepo := &MockRepository{
+		messages: []model.Message{
+			{ID: 1, Content: "stale", Recipient: "+905500000000", CreatedAt: now, ExpiresAt: now.Add(-time.Second)},
+			{ID: 2, Content: "otp", Recipient: "+905500000001", CreatedAt: now.Add(-20 * time.Minute), Tags: []string{"otp"}},
+			{ID: 3, Content: "fresh", Recipient: "+905500000002", CreatedAt: now.Add(-20 * time.Minute)},
+		},
+	}
+	cfg := &config.Config{
+		Message: config.MessageConfig{MaxAttempts: 3},
+		Webhook: config.WebhookConfig{URL: webhook.URL, Timeout: time.Second, FallbackMessageID: true},
+		Expiry:  config.ExpiryConfig{NormalTTL: time.Hour, TagTTLs: []string{"otp=10m"}},
+	}
+
+	processor := NewMessageProcessor(mockRepo, &MockStatusRepository{}, &MockCacheRepository{}, newTestSenders(t, cfg), zaptest.NewLogger(t), cfg)
+	processor.processMessages(context.Background(), context.Background())
+
+	expected := []model.MessageStatus{model.MessageExpired, model.MessageExpired, model.MessageSent}
+	if !reflect.DeepEqual(mockRepo.transitions, expected) {
+		t.Fatalf("Expected transitions %v, got %v", expected, mockRepo.transitions)
+	}
+	if calls != 1 {
+		t.Errorf("Expected only the fresh message to be sent, got %d webhook calls", calls)
+	}
+}
+
+func TestMessageProcessor_CreateMessage_DefaultsExpiry(t *testing.T) {
+	cfg := &config.Config{
+		Expiry: config.ExpiryConfig{HighTTL: 10 * time.Minute, TagTTLs: []string{"otp=5m"}},
+	}
+	processor := NewMessageProcessor(&MockRepository{}, &MockStatusRepository{}, &MockCacheRepository{}, newTestSenders(t, cfg), zaptest.NewLogger(t), cfg)
+
+	sendAt := time.Now().Add(time.Hour)
+	expiresAt := time.Now().Add(time.Minute).In(time.FixedZone("TRT", 3*60*60))
+	tests := []struct {
+		name     string
+		req      model.CreateMessageRequest
+		expected time.Time
+	}{
+		{
+			name:     "priority TTL",
+			req:      model.CreateMessageRequest{Priority: model.PriorityHigh},
+			expected: time.Now().Add(10 * time.Minute),
+		},
+		{
+			name:     "tag TTL wins over priority",
+			req:      model.CreateMessageRequest{Priority: model.PriorityHigh, Tags: []string{"otp"}},
+			expected: time.Now().Add(5 * time.Minute),
+		},
+		{
+			name:     "counted from sendAt",
+			req:      model.CreateMessageRequest{Priority: model.PriorityHigh, SendAt: sendAt},
+			expected: sendAt.Add(10 * time.Minute),
+		},
+		{
+			name:     "explicit expiry with an offset",
+			req:      model.CreateMessageRequest{Priority: model.PriorityHigh, ExpiresAt: expiresAt},
+			expected: expiresAt,
+		},
+		{
+			name: "no TTL",
+			req:  model.CreateMessageRequest{Priority: model.PriorityNormal},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.req.Content = "code"
+			tt.req.Recipient = "+905500000000"
+
+			msg, err := processor.CreateMessage(context.Background(), tt.req)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			if tt.expected.IsZero() {
+				if !msg.ExpiresAt.IsZero() {
+					t.Errorf("Expected no expiry, got %s", msg.ExpiresAt)
+				}
+				return
+			}
+			if diff := msg.ExpiresAt.Sub(tt.expected); diff < -time.Second || diff > time.Second {
+				t.Errorf("Expected expiry around %s, got %s", tt.expected, msg.ExpiresAt)
+			}
+			if msg.ExpiresAt.Location() != time.UTC {
+				t.Errorf("Expected the expiry in UTC, got %s", msg.ExpiresAt)
+			}
+		})
+	}
+}
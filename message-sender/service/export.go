@@ -0,0 +1,139 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"message-sender/model"
+)
+
+// exportColumns is the header row of a CSV export.
+var exportColumns = []string{
+	"id", "recipient", "content", "status", "priority", "tags", "metadata",
+	"createdAt", "sendAt", "sentAt", "messageId", "provider", "senderId", "attempts", "lastError",
+}
+
+// exportedStatuses are exported when the filter names no status.
+var exportedStatuses = []model.MessageStatus{model.MessageSent, model.MessageDelivered}
+
+// ExportMessages writes every message matching filter to w, oldest first, as
+// it is read from the database. Without a status filter the sent and
+// delivered messages are exported. Nothing is written to w before the first
+// rows have been read, so a failing query leaves w untouched. It returns how
+// many messages were written.
+func (s *MessageProcessor) ExportMessages(ctx context.Context, filter model.MessageFilter, format model.ExportFormat, w io.Writer) (int, error) {
+	if len(filter.Statuses) == 0 {
+		filter.Statuses = exportedStatuses
+	}
+
+	var begin func() error
+	var write func(model.Message) error
+	var flush func() error
+
+	switch format {
+	case model.ExportCSV:
+		writer := csv.NewWriter(w)
+		begin = func() error {
+			return writer.Write(exportColumns)
+		}
+		write = func(msg model.Message) error {
+			return writer.Write(exportRecord(msg))
+		}
+		flush = func() error {
+			writer.Flush()
+			return writer.Error()
+		}
+	case model.ExportNDJSON:
+		writer := bufio.NewWriter(w)
+		encoder := json.NewEncoder(writer)
+		begin = func() error {
+			return nil
+		}
+		write = func(msg model.Message) error {
+			return encoder.Encode(msg)
+		}
+		flush = writer.Flush
+	default:
+		return 0, fmt.Errorf("unsupported export format %q", format)
+	}
+
+	started := false
+	start := func() error {
+		if started {
+			return nil
+		}
+		started = true
+		if err := begin(); err != nil {
+			return fmt.Errorf("failed to write export header: %w", err)
+		}
+		return nil
+	}
+
+	exported := 0
+	err := s.repo.ExportMessages(ctx, filter, s.exportFetchSize(), func(msg model.Message) error {
+		if err := start(); err != nil {
+			return err
+		}
+		if err := write(msg); err != nil {
+			return fmt.Errorf("failed to write exported message: %w", err)
+		}
+		exported++
+		return nil
+	})
+	if err != nil {
+		return exported, fmt.Errorf("failed to export messages: %w", err)
+	}
+
+	// An export without messages still has its header
+	if err := start(); err != nil {
+		return exported, err
+	}
+	if err := flush(); err != nil {
+		return exported, fmt.Errorf("failed to write exported messages: %w", err)
+	}
+
+	s.logger.Info("Messages exported", zap.String("format", string(format)), zap.Int("count", exported))
+	return exported, nil
+}
+
+func exportRecord(msg model.Message) []string {
+	metadata := ""
+	if len(msg.Metadata) > 0 {
+		data, _ := json.Marshal(msg.Metadata)
+		metadata = string(data)
+	}
+
+	return []string{
+		strconv.FormatUint(uint64(msg.ID), 10),
+		msg.Recipient,
+		msg.Content,
+		string(msg.Status),
+		string(msg.Priority),
+		strings.Join(msg.Tags, ","),
+		metadata,
+		exportTime(msg.CreatedAt),
+		exportTime(msg.SendAt),
+		exportTime(msg.SentAt),
+		msg.MessageID,
+		msg.Provider,
+		msg.SenderID,
+		strconv.Itoa(msg.Attempts),
+		msg.LastError,
+	}
+}
+
+func exportTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
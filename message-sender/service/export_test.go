@@ -0,0 +1,82 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zaptest"
+
+	"message-sender/config"
+	"message-sender/model"
+)
+
+func TestMessageProcessor_ExportMessages(t *testing.T) {
+	sentAt := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+	mockRepo := &MockRepository{
+		messages: []model.Message{
+			{ID: 1, Recipient: "+905551111111", Content: "hello, world", Status: model.MessageSent, SentAt: sentAt, Tags: []string{"promo", "summer"}},
+			{ID: 2, Recipient: "+905552222222", Content: "bye", Status: model.MessageDelivered, Metadata: map[string]string{"campaign": "spring"}},
+		},
+	}
+	cfg := &config.Config{}
+	processor := NewMessageProcessor(mockRepo, &MockStatusRepository{}, &MockCacheRepository{}, newTestSenders(t, cfg), zaptest.NewLogger(t), cfg)
+
+	var csv bytes.Buffer
+	exported, err := processor.ExportMessages(context.Background(), model.MessageFilter{}, model.ExportCSV, &csv)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if exported != 2 {
+		t.Errorf("Expected 2 exported messages, got %d", exported)
+	}
+	if !reflect.DeepEqual(mockRepo.filter.Statuses, []model.MessageStatus{model.MessageSent, model.MessageDelivered}) {
+		t.Errorf("Expected sent and delivered messages to be exported by default, got %v", mockRepo.filter.Statuses)
+	}
+
+	lines := strings.Split(strings.TrimSpace(csv.String()), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "id,recipient,content,") {
+		t.Fatalf("Expected a header and 2 rows, got %q", csv.String())
+	}
+	if !strings.HasPrefix(lines[1], `1,+905551111111,"hello, world",sent,,"promo,summer",,,,2024-01-02T15:04:05Z,`) {
+		t.Errorf("Unexpected first row %q", lines[1])
+	}
+
+	var ndjson bytes.Buffer
+	filter := model.MessageFilter{Statuses: []model.MessageStatus{model.MessageDelivered}}
+	if _, err := processor.ExportMessages(context.Background(), filter, model.ExportNDJSON, &ndjson); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !reflect.DeepEqual(mockRepo.filter.Statuses, filter.Statuses) {
+		t.Errorf("Expected the status filter to be kept, got %v", mockRepo.filter.Statuses)
+	}
+	if lines := strings.Split(strings.TrimSpace(ndjson.String()), "\n"); len(lines) != 2 || !strings.Contains(lines[1], `"metadata":{"campaign":"spring"}`) {
+		t.Errorf("Expected one JSON object per message, got %q", ndjson.String())
+	}
+}
+
+func TestMessageProcessor_ExportMessages_WritesNothingOnQueryError(t *testing.T) {
+	mockRepo := &MockRepository{exportErr: errors.New("connection refused")}
+	cfg := &config.Config{}
+	processor := NewMessageProcessor(mockRepo, &MockStatusRepository{}, &MockCacheRepository{}, newTestSenders(t, cfg), zaptest.NewLogger(t), cfg)
+
+	var out bytes.Buffer
+	if _, err := processor.ExportMessages(context.Background(), model.MessageFilter{}, model.ExportCSV, &out); err == nil {
+		t.Fatal("Expected an error")
+	}
+	if out.Len() != 0 {
+		t.Errorf("Expected nothing to be written, got %q", out.String())
+	}
+
+	mockRepo.exportErr = nil
+	if _, err := processor.ExportMessages(context.Background(), model.MessageFilter{}, model.ExportCSV, &out); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !strings.HasPrefix(out.String(), "id,recipient,content,") {
+		t.Errorf("Expected an empty export to have its header, got %q", out.String())
+	}
+}
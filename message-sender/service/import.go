@@ -0,0 +1,378 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"message-sender/model"
+)
+
+// maxImportBatchSize keeps the eight bind parameters written per message
+// under the 65535 parameter limit of Postgres.
+const maxImportBatchSize = 8000
+
+// maxImportLineSize is the longest NDJSON line an import accepts.
+const maxImportLineSize = 1 << 20
+
+const metadataColumnPrefix = "metadata."
+
+// rowError rejects a single row of an import without stopping it.
+type rowError struct {
+	field   string
+	message string
+}
+
+func (e *rowError) Error() string {
+	return e.message
+}
+
+// importReader reads the rows of an upload one at a time. next returns a
+// *rowError for a row that cannot be decoded and io.EOF after the last row.
+type importReader interface {
+	next() (int, model.CreateMessageRequest, error)
+}
+
+func newImportReader(format model.ImportFormat, r io.Reader) (importReader, error) {
+	switch format {
+	case model.ImportCSV:
+		return newCSVImportReader(r)
+	case model.ImportNDJSON:
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 0, 64*1024), maxImportLineSize)
+		return &ndjsonImportReader{scanner: scanner}, nil
+	}
+	return nil, fmt.Errorf("%w: unsupported format %q", ErrInvalidImport, format)
+}
+
+// csvImportReader reads a CSV upload whose header row names the columns:
+// recipient, content, priority, sendAt, expiresAt, tags and metadata.<key>.
+type csvImportReader struct {
+	reader  *csv.Reader
+	columns []string
+	row     int
+}
+
+func newCSVImportReader(r io.Reader) (*csvImportReader, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%w: missing header row", ErrInvalidImport)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to read header row: %v", ErrInvalidImport, err)
+	}
+
+	seen := make(map[string]bool, len(header))
+	for i, column := range header {
+		column = strings.TrimSpace(column)
+		if i == 0 {
+			// Spreadsheets often save CSV with a byte order mark
+			column = strings.TrimPrefix(column, "\ufeff")
+		}
+
+		switch {
+		case column == "recipient", column == "content", column == "priority",
+			column == "sendAt", column == "expiresAt", column == "tags":
+		case strings.HasPrefix(column, metadataColumnPrefix) && len(column) > len(metadataColumnPrefix):
+		default:
+			return nil, fmt.Errorf("%w: unknown column %q", ErrInvalidImport, column)
+		}
+
+		if seen[column] {
+			return nil, fmt.Errorf("%w: duplicate column %q", ErrInvalidImport, column)
+		}
+		seen[column] = true
+		header[i] = column
+	}
+
+	if !seen["recipient"] || !seen["content"] {
+		return nil, fmt.Errorf("%w: the recipient and content columns are required", ErrInvalidImport)
+	}
+
+	return &csvImportReader{reader: reader, columns: header}, nil
+}
+
+func (c *csvImportReader) next() (int, model.CreateMessageRequest, error) {
+	var req model.CreateMessageRequest
+
+	record, err := c.reader.Read()
+	if errors.Is(err, io.EOF) {
+		return 0, req, io.EOF
+	}
+	c.row++
+
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return c.row, req, &rowError{message: parseErr.Err.Error()}
+	}
+	if err != nil {
+		return c.row, req, err
+	}
+
+	for i, value := range record {
+		column := c.columns[i]
+		value = strings.TrimSpace(value)
+
+		switch column {
+		case "recipient":
+			req.Recipient = value
+		case "content":
+			req.Content = value
+		case "priority":
+			req.Priority = model.MessagePriority(value)
+		case "sendAt":
+			if req.SendAt, err = parseImportTime(column, value); err != nil {
+				return c.row, req, err
+			}
+		case "expiresAt":
+			if req.ExpiresAt, err = parseImportTime(column, value); err != nil {
+				return c.row, req, err
+			}
+		case "tags":
+			for _, tag := range strings.Split(value, ",") {
+				if tag = strings.TrimSpace(tag); tag != "" {
+					req.Tags = append(req.Tags, tag)
+				}
+			}
+		default:
+			if value == "" {
+				continue
+			}
+			if req.Metadata == nil {
+				req.Metadata = make(map[string]string)
+			}
+			req.Metadata[strings.TrimPrefix(column, metadataColumnPrefix)] = value
+		}
+	}
+
+	return c.row, req, nil
+}
+
+func parseImportTime(field, value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, &rowError{field: field, message: "must be an RFC 3339 time such as 2024-01-02T15:04:05Z"}
+	}
+	return t, nil
+}
+
+// ndjsonImportReader reads one CreateMessageRequest per line. Blank lines are
+// skipped, and rows are numbered by line.
+type ndjsonImportReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+func (n *ndjsonImportReader) next() (int, model.CreateMessageRequest, error) {
+	var req model.CreateMessageRequest
+
+	for n.scanner.Scan() {
+		n.line++
+
+		line := strings.TrimSpace(n.scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		if err := json.Unmarshal([]byte(line), &req); err != nil {
+			return n.line, req, &rowError{message: fmt.Sprintf("invalid JSON: %v", err)}
+		}
+		return n.line, req, nil
+	}
+
+	if err := n.scanner.Err(); err != nil {
+		return n.line + 1, req, err
+	}
+	return 0, req, io.EOF
+}
+
+// importBatch collects the rows of an import until they are written.
+type importBatch struct {
+	messages  []model.Message
+	rowErrors []model.ImportRowError
+}
+
+// ImportMessages reads the rows of an upload, validates every one like
+// CreateMessage does and writes the valid ones in batches. Invalid rows are
+// recorded as row errors of the returned job. An upload that cannot be read
+// to the end marks the job failed, keeping the rows written before that.
+func (s *MessageProcessor) ImportMessages(ctx context.Context, format model.ImportFormat, r io.Reader) (*model.ImportJob, error) {
+	rows, err := newImportReader(format, r)
+	if err != nil {
+		return nil, err
+	}
+
+	job := &model.ImportJob{Format: format, Status: model.ImportRunning}
+	if err := s.repo.CreateImportJob(ctx, job); err != nil {
+		return nil, fmt.Errorf("failed to create import job: %w", err)
+	}
+
+	s.logger.Info("Import started", zap.Uint("importID", job.ID), zap.String("format", string(format)))
+
+	err = s.importRows(ctx, job, rows)
+	if err != nil {
+		job.Status = model.ImportFailed
+		job.Error = err.Error()
+	} else if job.Status == model.ImportRunning {
+		job.Status = model.ImportCompleted
+	}
+	job.FinishedAt = time.Now()
+
+	// The job is finished even when the client went away
+	if updateErr := s.repo.UpdateImportJob(context.WithoutCancel(ctx), job); updateErr != nil {
+		return nil, fmt.Errorf("failed to finish import job: %w", updateErr)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Import finished",
+		zap.Uint("importID", job.ID),
+		zap.String("status", string(job.Status)),
+		zap.Int("totalRows", job.TotalRows),
+		zap.Int("importedRows", job.ImportedRows),
+		zap.Int("failedRows", job.FailedRows))
+	return job, nil
+}
+
+// importRows reads rows until the upload ends. An upload that cannot be read
+// to the end marks job failed, and the rows read before that are still
+// written. It returns an error only when writing the rows fails.
+func (s *MessageProcessor) importRows(ctx context.Context, job *model.ImportJob, rows importReader) error {
+	batchSize := s.importBatchSize()
+	maxErrors := s.importMaxErrors()
+	keptErrors := 0
+	scheduled := false
+
+	batch := &importBatch{}
+	defer func() {
+		if scheduled {
+			s.scheduleChanged()
+		}
+	}()
+
+	for {
+		row, req, err := rows.next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		var rerr *rowError
+		if err != nil && !errors.As(err, &rerr) {
+			job.Status = model.ImportFailed
+			job.Error = fmt.Sprintf("failed to read row %d: %v", row, err)
+			return s.flushImport(ctx, job, batch)
+		}
+
+		job.TotalRows++
+		now := time.Now()
+
+		var rowErrors []model.ImportRowError
+		if rerr != nil {
+			rowErrors = append(rowErrors, model.ImportRowError{Row: row, Field: rerr.field, Message: rerr.message})
+		} else if err := s.validateMessage(&req, now); err != nil {
+			var verr *ValidationError
+			if !errors.As(err, &verr) {
+				return err
+			}
+			for _, field := range verr.Fields {
+				rowErrors = append(rowErrors, model.ImportRowError{Row: row, Field: field.Field, Message: field.Message})
+			}
+		}
+
+		if len(rowErrors) > 0 {
+			job.FailedRows++
+			if keptErrors < maxErrors {
+				if len(rowErrors) > maxErrors-keptErrors {
+					rowErrors = rowErrors[:maxErrors-keptErrors]
+				}
+				batch.rowErrors = append(batch.rowErrors, rowErrors...)
+				keptErrors += len(rowErrors)
+			}
+		} else {
+			msg := s.newMessage(req, now)
+			batch.messages = append(batch.messages, msg)
+			scheduled = scheduled || msg.SendAt.After(now)
+		}
+
+		if len(batch.messages) >= batchSize || len(batch.rowErrors) >= batchSize {
+			if err := s.flushImport(ctx, job, batch); err != nil {
+				return err
+			}
+		}
+	}
+
+	return s.flushImport(ctx, job, batch)
+}
+
+// flushImport writes the pending rows of an import and records its progress.
+func (s *MessageProcessor) flushImport(ctx context.Context, job *model.ImportJob, batch *importBatch) error {
+	if len(batch.messages) > 0 {
+		imported, err := s.repo.SaveMessages(ctx, batch.messages)
+		if err != nil {
+			return fmt.Errorf("failed to save imported messages: %w", err)
+		}
+		job.ImportedRows += int(imported)
+	}
+
+	if err := s.repo.SaveImportErrors(ctx, job.ID, batch.rowErrors); err != nil {
+		return fmt.Errorf("failed to save import errors: %w", err)
+	}
+
+	batch.messages = batch.messages[:0]
+	batch.rowErrors = batch.rowErrors[:0]
+
+	if err := s.repo.UpdateImportJob(ctx, job); err != nil {
+		return fmt.Errorf("failed to update import job: %w", err)
+	}
+	return nil
+}
+
+func (s *MessageProcessor) GetImportJob(ctx context.Context, id uint) (*model.ImportJob, error) {
+	job, err := s.repo.GetImportJob(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get import job: %w", err)
+	}
+	if job == nil {
+		return nil, ErrImportNotFound
+	}
+	return job, nil
+}
+
+func (s *MessageProcessor) GetImportErrors(ctx context.Context, id uint, page, limit int) (*model.ImportErrorsResponse, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	if _, err := s.GetImportJob(ctx, id); err != nil {
+		return nil, err
+	}
+
+	rowErrors, count, err := s.repo.GetImportErrors(ctx, id, page, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get import errors: %w", err)
+	}
+
+	return &model.ImportErrorsResponse{
+		Errors: rowErrors,
+		Count:  count,
+	}, nil
+}
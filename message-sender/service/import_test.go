@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap/zaptest"
+
+	"message-sender/config"
+	"message-sender/model"
+)
+
+func TestMessageProcessor_ImportMessages_CSV(t *testing.T) {
+	cfg := &config.Config{
+		Import: config.ImportConfig{BatchSize: 2},
+	}
+	mockRepo := &MockRepository{}
+	processor := NewMessageProcessor(mockRepo, &MockStatusRepository{}, &MockCacheRepository{}, newTestSenders(t, cfg), zaptest.NewLogger(t), cfg)
+
+	upload := "\ufeffrecipient,content,priority,tags,metadata.campaign\n" +
+		"+905551111111,hello,high,\"promo, summer\",spring-sale\n" +
+		"05551111111,,normal,,\n" +
+		"+905552222222,\"bare\"quote,bulk,,\n" +
+		"+905553333333,bye,,,\n"
+
+	job, err := processor.ImportMessages(context.Background(), model.ImportCSV, strings.NewReader(upload))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if job.Status != model.ImportCompleted || job.TotalRows != 4 || job.ImportedRows != 2 || job.FailedRows != 2 {
+		t.Errorf("Expected a completed job with 2 of 4 rows imported, got %+v", job)
+	}
+
+	if len(mockRepo.saved) != 2 {
+		t.Fatalf("Expected 2 saved messages, got %d", len(mockRepo.saved))
+	}
+	first := mockRepo.saved[0]
+	if first.Priority != model.PriorityHigh || !reflect.DeepEqual(first.Tags, []string{"promo", "summer"}) || first.Metadata["campaign"] != "spring-sale" {
+		t.Errorf("Expected the first row's priority, tags and metadata, got %+v", first)
+	}
+	if mockRepo.saved[1].Priority != model.PriorityNormal {
+		t.Errorf("Expected the default priority, got %q", mockRepo.saved[1].Priority)
+	}
+
+	rows := make([]int, len(mockRepo.importErrors))
+	for i, rowErr := range mockRepo.importErrors {
+		rows[i] = rowErr.Row
+	}
+	if !reflect.DeepEqual(rows, []int{2, 2, 3}) {
+		t.Errorf("Expected errors for rows [2 2 3], got %v", mockRepo.importErrors)
+	}
+}
+
+func TestMessageProcessor_ImportMessages_NDJSON(t *testing.T) {
+	cfg := &config.Config{}
+	mockRepo := &MockRepository{}
+	processor := NewMessageProcessor(mockRepo, &MockStatusRepository{}, &MockCacheRepository{}, newTestSenders(t, cfg), zaptest.NewLogger(t), cfg)
+
+	upload := `{"recipient": "+905551111111", "content": "hello"}
+
+{"recipient": "+905552222222", "content":
+{"recipient": "+905553333333", "content": "hi", "priority": "urgent"}
+`
+
+	job, err := processor.ImportMessages(context.Background(), model.ImportNDJSON, strings.NewReader(upload))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if job.TotalRows != 3 || job.ImportedRows != 1 || job.FailedRows != 2 {
+		t.Errorf("Expected 1 of 3 rows imported, got %+v", job)
+	}
+
+	expected := []model.ImportRowError{
+		{Row: 3, Message: mockRepo.importErrors[0].Message},
+		{Row: 4, Field: "priority", Message: "must be high, normal or bulk"},
+	}
+	if !reflect.DeepEqual(mockRepo.importErrors, expected) {
+		t.Errorf("Expected row errors %v, got %v", expected, mockRepo.importErrors)
+	}
+	if !strings.HasPrefix(mockRepo.importErrors[0].Message, "invalid JSON") {
+		t.Errorf("Expected an invalid JSON error, got %q", mockRepo.importErrors[0].Message)
+	}
+}
+
+func TestMessageProcessor_ImportMessages_UnknownColumn(t *testing.T) {
+	cfg := &config.Config{}
+	mockRepo := &MockRepository{}
+	processor := NewMessageProcessor(mockRepo, &MockStatusRepository{}, &MockCacheRepository{}, newTestSenders(t, cfg), zaptest.NewLogger(t), cfg)
+
+	_, err := processor.ImportMessages(context.Background(), model.ImportCSV, strings.NewReader("recipient,content,colour\n"))
+	if !errors.Is(err, ErrInvalidImport) {
+		t.Errorf("Expected ErrInvalidImport, got %v", err)
+	}
+	if mockRepo.importJob.ID != 0 {
+		t.Errorf("Expected no import job to be created")
+	}
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+
+	"message-sender/model"
+	"message-sender/repository"
+)
+
+const defaultLeaderTTL = 5 * time.Second
+
+// LeaderElector campaigns for a renewable lock so that only one replica runs
+// the message processing loop at a time. The lock is renewed three times per
+// TTL, so a dead leader is replaced within roughly one TTL.
+type LeaderElector struct {
+	repo       repository.LeaderRepository
+	logger     *zap.Logger
+	instanceID string
+	ttl        time.Duration
+	leader     atomic.Bool
+	renewedAt  time.Time
+}
+
+func NewLeaderElector(
+	repo repository.LeaderRepository,
+	logger *zap.Logger,
+	instanceID string,
+	ttl time.Duration,
+) *LeaderElector {
+	if ttl <= 0 {
+		ttl = defaultLeaderTTL
+	}
+
+	return &LeaderElector{
+		repo:       repo,
+		logger:     logger,
+		instanceID: instanceID,
+		ttl:        ttl,
+	}
+}
+
+func (e *LeaderElector) IsLeader() bool {
+	return e.leader.Load()
+}
+
+func (e *LeaderElector) Info() model.LeaderInfo {
+	return model.LeaderInfo{
+		Enabled:    true,
+		InstanceID: e.instanceID,
+		IsLeader:   e.IsLeader(),
+	}
+}
+
+// Run campaigns until ctx is done and releases the lock on the way out so a
+// follower can take over without waiting for the TTL.
+func (e *LeaderElector) Run(ctx context.Context) error {
+	ticker := time.NewTicker(e.ttl / 3)
+	defer ticker.Stop()
+
+	e.campaign(ctx)
+
+	for {
+		select {
+		case <-ticker.C:
+			e.campaign(ctx)
+		case <-ctx.Done():
+			if e.leader.Swap(false) {
+				releaseCtx, cancel := context.WithTimeout(context.Background(), time.Second)
+				defer cancel()
+
+				if err := e.repo.ReleaseLeadership(releaseCtx, e.instanceID); err != nil {
+					e.logger.Error("Failed to release leadership", zap.Error(err))
+				}
+				e.logger.Info("Leadership released", zap.String("instanceID", e.instanceID))
+			}
+			return nil
+		}
+	}
+}
+
+func (e *LeaderElector) campaign(ctx context.Context) {
+	acquired, err := e.repo.AcquireLeadership(ctx, e.instanceID, e.ttl)
+	if err != nil {
+		e.logger.Error("Failed to campaign for leadership", zap.Error(err))
+
+		// The lock may already have expired in Redis; step down rather than
+		// risk two leaders.
+		if e.IsLeader() && time.Since(e.renewedAt) >= e.ttl {
+			e.leader.Store(false)
+			e.logger.Warn("Leadership lost", zap.String("instanceID", e.instanceID))
+		}
+		return
+	}
+
+	if acquired {
+		e.renewedAt = time.Now()
+	}
+
+	if was := e.leader.Swap(acquired); was != acquired {
+		if acquired {
+			e.logger.Info("Leadership acquired", zap.String("instanceID", e.instanceID))
+		} else {
+			e.logger.Warn("Leadership lost", zap.String("instanceID", e.instanceID))
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zaptest"
+)
+
+type MockLeaderRepository struct {
+	holder string
+	err    error
+}
+
+func (m *MockLeaderRepository) AcquireLeadership(ctx context.Context, instanceID string, ttl time.Duration) (bool, error) {
+	if m.err != nil {
+		return false, m.err
+	}
+	if m.holder == "" || m.holder == instanceID {
+		m.holder = instanceID
+		return true, nil
+	}
+	return false, nil
+}
+
+func (m *MockLeaderRepository) ReleaseLeadership(ctx context.Context, instanceID string) error {
+	if m.holder == instanceID {
+		m.holder = ""
+	}
+	return nil
+}
+
+func TestLeaderElector_Campaign(t *testing.T) {
+	repo := &MockLeaderRepository{}
+	logger := zaptest.NewLogger(t)
+
+	first := NewLeaderElector(repo, logger, "instance-1", time.Second)
+	second := NewLeaderElector(repo, logger, "instance-2", time.Second)
+
+	first.campaign(context.Background())
+	second.campaign(context.Background())
+
+	if !first.IsLeader() {
+		t.Error("Expected instance-1 to be the leader")
+	}
+	if second.IsLeader() {
+		t.Error("Expected instance-2 to be a follower")
+	}
+
+	// instance-1 dies and its lock expires
+	repo.holder = ""
+	second.campaign(context.Background())
+
+	if !second.IsLeader() {
+		t.Error("Expected instance-2 to take over leadership")
+	}
+}
+
+func TestLeaderElector_StepsDownWhenRenewalFails(t *testing.T) {
+	repo := &MockLeaderRepository{}
+	elector := NewLeaderElector(repo, zaptest.NewLogger(t), "instance-1", time.Second)
+
+	elector.campaign(context.Background())
+	if !elector.IsLeader() {
+		t.Fatal("Expected instance-1 to be the leader")
+	}
+
+	repo.err = errors.New("connection refused")
+	elector.renewedAt = time.Now().Add(-2 * time.Second)
+	elector.campaign(context.Background())
+
+	if elector.IsLeader() {
+		t.Error("Expected leadership to be dropped after the TTL passed without renewal")
+	}
+}
+
+func TestLeaderElector_RunReleasesLeadership(t *testing.T) {
+	repo := &MockLeaderRepository{}
+	elector := NewLeaderElector(repo, zaptest.NewLogger(t), "instance-1", time.Second)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- elector.Run(ctx)
+	}()
+
+	deadline := time.Now().Add(time.Second)
+	for !elector.IsLeader() && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !elector.IsLeader() {
+		t.Fatal("Expected instance-1 to become the leader")
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if repo.holder != "" {
+		t.Errorf("Expected leadership to be released, still held by %s", repo.holder)
+	}
+}
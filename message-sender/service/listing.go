@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"message-sender/model"
+)
+
+// encodeCursor turns a page cursor into the opaque string handed to clients.
+func encodeCursor(cursor model.PageCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(s string) (model.PageCursor, error) {
+	var cursor model.PageCursor
+	if s == "" {
+		return cursor, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return cursor, ErrInvalidCursor
+	}
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID == 0 {
+		return cursor, ErrInvalidCursor
+	}
+	return cursor, nil
+}
+
+// ListMessages returns a page of the messages matching filter, newest first,
+// with cursors to the pages around it. The total is only counted when
+// withCount is set, since it has to visit every matching row.
+func (s *MessageProcessor) ListMessages(ctx context.Context, filter model.MessageFilter, cursor string, limit int, withCount bool) (*model.MessageListResponse, error) {
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	position, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	// One extra row tells whether there is a page beyond this one
+	messages, err := s.repo.ListMessages(ctx, filter, position, limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list messages: %w", err)
+	}
+
+	more := len(messages) > limit
+	if more {
+		if position.Backward {
+			messages = messages[1:]
+		} else {
+			messages = messages[:limit]
+		}
+	}
+
+	response := &model.MessageListResponse{Messages: messages}
+	if response.Messages == nil {
+		response.Messages = []model.Message{}
+	}
+
+	if len(messages) > 0 {
+		newest, oldest := messages[0].ID, messages[len(messages)-1].ID
+
+		// Paging backward came from an older page and forward from a newer one
+		if more || (position.ID != 0 && position.Backward) {
+			response.NextCursor = encodeCursor(model.PageCursor{ID: oldest})
+		}
+		if (more && position.Backward) || (position.ID != 0 && !position.Backward) {
+			response.PrevCursor = encodeCursor(model.PageCursor{ID: newest, Backward: true})
+		}
+	}
+
+	if withCount {
+		count, err := s.repo.CountMessages(ctx, filter)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count messages: %w", err)
+		}
+		response.Count = &count
+	}
+
+	return response, nil
+}
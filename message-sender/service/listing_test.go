@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"go.uber.org/zap/zaptest"
+
+	"message-sender/config"
+	"message-sender/model"
+)
+
+func TestMessageProcessor_ListMessages_Cursors(t *testing.T) {
+	mockRepo := &MockRepository{}
+	for id := uint(1); id <= 5; id++ {
+		mockRepo.messages = append(mockRepo.messages, model.Message{ID: id})
+	}
+	cfg := &config.Config{}
+	processor := NewMessageProcessor(mockRepo, &MockStatusRepository{}, &MockCacheRepository{}, newTestSenders(t, cfg), zaptest.NewLogger(t), cfg)
+
+	list := func(cursor string) *model.MessageListResponse {
+		t.Helper()
+		page, err := processor.ListMessages(context.Background(), model.MessageFilter{}, cursor, 2, false)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		return page
+	}
+	ids := func(page *model.MessageListResponse) []uint {
+		ids := make([]uint, len(page.Messages))
+		for i, msg := range page.Messages {
+			ids[i] = msg.ID
+		}
+		return ids
+	}
+
+	first := list("")
+	if !reflect.DeepEqual(ids(first), []uint{5, 4}) || first.PrevCursor != "" || first.NextCursor == "" {
+		t.Fatalf("Expected the first page [5 4] with only a next cursor, got %v %+v", ids(first), first)
+	}
+	if first.Count != nil {
+		t.Errorf("Expected no count unless requested")
+	}
+
+	second := list(first.NextCursor)
+	if !reflect.DeepEqual(ids(second), []uint{3, 2}) || second.PrevCursor == "" || second.NextCursor == "" {
+		t.Fatalf("Expected the second page [3 2] with both cursors, got %v %+v", ids(second), second)
+	}
+
+	last := list(second.NextCursor)
+	if !reflect.DeepEqual(ids(last), []uint{1}) || last.NextCursor != "" {
+		t.Fatalf("Expected the last page [1] without a next cursor, got %v %+v", ids(last), last)
+	}
+
+	back := list(last.PrevCursor)
+	if !reflect.DeepEqual(ids(back), []uint{3, 2}) || back.PrevCursor == "" || back.NextCursor == "" {
+		t.Fatalf("Expected to page back to [3 2], got %v %+v", ids(back), back)
+	}
+
+	top := list(back.PrevCursor)
+	if !reflect.DeepEqual(ids(top), []uint{5, 4}) || top.PrevCursor != "" {
+		t.Errorf("Expected to page back to the first page, got %v %+v", ids(top), top)
+	}
+}
+
+func TestMessageProcessor_ListMessages_InvalidCursor(t *testing.T) {
+	cfg := &config.Config{}
+	processor := NewMessageProcessor(&MockRepository{}, &MockStatusRepository{}, &MockCacheRepository{}, newTestSenders(t, cfg), zaptest.NewLogger(t), cfg)
+
+	if _, err := processor.ListMessages(context.Background(), model.MessageFilter{}, "not a cursor", 10, true); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("Expected ErrInvalidCursor, got %v", err)
+	}
+}
@@ -1,11 +1,8 @@
 package service
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
-	"net/http"
 	"sync"
 	"time"
 
@@ -14,104 +11,354 @@ import (
 	"message-sender/config"
 	"message-sender/model"
 	"message-sender/repository"
+	"message-sender/sender"
 )
 
 type MessageProcessor struct {
-	repo          repository.Repository
-	statusRepo    repository.ServiceStatusRepository
-	cacheRepo     repository.CacheRepository
-	logger        *zap.Logger
-	cfg           *config.Config
-	ticker        *time.Ticker
-	stopChan      chan struct{}
-	processingMux sync.Mutex
-	httpClient    *http.Client
+	repo            repository.Repository
+	statusRepo      repository.ServiceStatusRepository
+	cacheRepo       repository.CacheRepository
+	logger          *zap.Logger
+	cfg             *config.Config
+	loop            *processingLoop
+	processingMux   sync.Mutex
+	senders         *sender.Registry
+	router          *sender.Router
+	elector         *LeaderElector
+	rateLimiter     *tokenBucket
+	recipientLimits repository.RecipientLimitRepository
+	rescheduled     chan struct{}
 }
 
 func NewMessageProcessor(
 	repo repository.Repository,
 	statusRepo repository.ServiceStatusRepository,
 	cacheRepo repository.CacheRepository,
+	senders *sender.Registry,
 	logger *zap.Logger,
 	cfg *config.Config,
 ) *MessageProcessor {
 	return &MessageProcessor{
-		repo:       repo,
-		statusRepo: statusRepo,
-		cacheRepo:  cacheRepo,
-		logger:     logger,
-		cfg:        cfg,
-		stopChan:   make(chan struct{}),
-		httpClient: &http.Client{
-			Timeout: cfg.Webhook.Timeout,
-		},
+		repo:        repo,
+		statusRepo:  statusRepo,
+		cacheRepo:   cacheRepo,
+		senders:     senders,
+		logger:      logger,
+		cfg:         cfg,
+		rateLimiter: newTokenBucket(cfg.RateLimit.GlobalRate, cfg.RateLimit.GlobalBurst),
+		rescheduled: make(chan struct{}, 1),
 	}
 }
 
+// UseLeaderElection restricts message processing to the instance currently
+// holding leadership. Followers keep their loop running but skip every tick.
+func (s *MessageProcessor) UseLeaderElection(elector *LeaderElector) {
+	s.elector = elector
+}
+
+// UseRouter sends messages through the provider and sender ID chosen by the
+// routing rules.
+func (s *MessageProcessor) UseRouter(router *sender.Router) {
+	s.router = router
+}
+
+// StartService marks the service as running for the whole cluster and
+// broadcasts the command so that every replica starts its loop.
 func (s *MessageProcessor) StartService(ctx context.Context) error {
 	s.processingMux.Lock()
 	defer s.processingMux.Unlock()
 
-	status, err := s.statusRepo.GetServiceStatus(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to get service status: %w", err)
+	if err := s.statusRepo.SetServiceStatus(ctx, model.StatusRunning); err != nil {
+		return fmt.Errorf("failed to set service status: %w", err)
 	}
 
-	if status == model.StatusRunning {
-		return nil
+	if err := s.statusRepo.PublishControl(ctx, model.ActionStart); err != nil {
+		return fmt.Errorf("failed to publish start command: %w", err)
 	}
 
-	if err := s.statusRepo.SetServiceStatus(ctx, model.StatusRunning); err != nil {
+	s.startLoop()
+	return nil
+}
+
+// StopService marks the service as stopped for the whole cluster and
+// broadcasts the command so that every replica stops its loop.
+func (s *MessageProcessor) StopService(ctx context.Context) error {
+	s.processingMux.Lock()
+	defer s.processingMux.Unlock()
+
+	if err := s.statusRepo.SetServiceStatus(ctx, model.StatusStopped); err != nil {
 		return fmt.Errorf("failed to set service status: %w", err)
 	}
 
-	s.ticker = time.NewTicker(s.cfg.Message.ProcessInterval)
+	if err := s.statusRepo.PublishControl(ctx, model.ActionStop); err != nil {
+		return fmt.Errorf("failed to publish stop command: %w", err)
+	}
+
+	s.stopLoop()
+	return nil
+}
+
+// processingLoop is one run of the local ticker loop, from startLoop to
+// stopLoop.
+type processingLoop struct {
+	cancel      context.CancelFunc
+	cancelSends context.CancelFunc
+	done        chan struct{}
+}
+
+// startLoop starts the local processing loop. Every call after a stopLoop
+// starts a fresh loop, so the service can be started and stopped any number
+// of times. The caller must hold processingMux.
+func (s *MessageProcessor) startLoop() {
+	if s.loop != nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	sendCtx, cancelSends := context.WithCancel(context.Background())
+	loop := &processingLoop{
+		cancel:      cancel,
+		cancelSends: cancelSends,
+		done:        make(chan struct{}),
+	}
+	s.loop = loop
+
+	interval := s.cfg.Message.ProcessInterval
+	ticker := time.NewTicker(interval)
 
 	go func() {
-		s.processMessages(context.Background())
+		defer close(loop.done)
+		defer cancelSends()
+		defer ticker.Stop()
+
+		// Scheduled messages that fall due between ticks get a tick of their
+		// own instead of waiting for the next one.
+		var wake wakeUp
+		defer wake.stop()
+
+		nextTick := time.Now().Add(interval)
+		s.processMessages(ctx, sendCtx)
+		wake.set(s.nextWakeUp(ctx, nextTick))
 
 		for {
 			select {
-			case <-s.ticker.C:
-				s.processMessages(context.Background())
-			case <-s.stopChan:
-				s.ticker.Stop()
+			case <-ticker.C:
+				nextTick = time.Now().Add(interval)
+			case <-wake.C():
+			case <-s.rescheduled:
+				wake.set(s.nextWakeUp(ctx, nextTick))
+				continue
+			case <-ctx.Done():
 				return
 			}
+
+			s.processMessages(ctx, sendCtx)
+			wake.set(s.nextWakeUp(ctx, nextTick))
 		}
 	}()
 
 	s.logger.Info("Message sending service started")
-	return nil
 }
 
-func (s *MessageProcessor) StopService(ctx context.Context) error {
+// stopLoop stops the local processing loop from claiming new messages and
+// returns a channel that is closed once the loop has returned. In-flight sends
+// are given the drain deadline to finish before they are cancelled. The caller
+// must hold processingMux.
+func (s *MessageProcessor) stopLoop() <-chan struct{} {
+	if s.loop == nil {
+		done := make(chan struct{})
+		close(done)
+		return done
+	}
+
+	loop := s.loop
+	s.loop = nil
+	loop.cancel()
+
+	drainTimeout := s.drainTimeout()
+	go func() {
+		timer := time.NewTimer(drainTimeout)
+		defer timer.Stop()
+
+		select {
+		case <-loop.done:
+		case <-timer.C:
+			s.logger.Warn("Drain deadline exceeded, cancelling in-flight sends",
+				zap.Duration("drainTimeout", drainTimeout))
+			loop.cancelSends()
+		}
+	}()
+
+	s.logger.Info("Message sending service stopped")
+	return loop.done
+}
+
+func (s *MessageProcessor) isLoopRunning() bool {
 	s.processingMux.Lock()
 	defer s.processingMux.Unlock()
 
+	return s.loop != nil
+}
+
+func (s *MessageProcessor) GetServiceStatus(ctx context.Context) (model.ServiceStatus, error) {
+	return s.statusRepo.GetServiceStatus(ctx)
+}
+
+func (s *MessageProcessor) GetServiceDetails(ctx context.Context) (*model.ServiceStatusResponse, error) {
 	status, err := s.statusRepo.GetServiceStatus(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to get service status: %w", err)
+		return nil, fmt.Errorf("failed to get service status: %w", err)
 	}
 
-	if status == model.StatusStopped {
-		return nil
+	lanes, err := s.GetLaneBacklog(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	if s.ticker != nil {
-		close(s.stopChan)
+	return &model.ServiceStatusResponse{
+		Status:    status,
+		Running:   s.isLoopRunning(),
+		Leader:    s.GetLeaderInfo(),
+		Breakers:  s.GetBreakers(),
+		Throttles: s.senders.Throttles(),
+		Lanes:     lanes,
+	}, nil
+}
+
+// GetLaneBacklog returns the backlog and quota of every priority lane, from
+// the highest priority to the lowest.
+func (s *MessageProcessor) GetLaneBacklog(ctx context.Context) ([]model.LaneBacklog, error) {
+	backlog, err := s.repo.LaneBacklog(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get lane backlog: %w", err)
 	}
 
-	if err := s.statusRepo.SetServiceStatus(ctx, model.StatusStopped); err != nil {
-		return fmt.Errorf("failed to set service status: %w", err)
+	lanes := make([]model.LaneBacklog, 0, len(model.Priorities))
+	for _, priority := range model.Priorities {
+		lane := backlog[priority]
+		lane.Priority = priority
+		lane.Quota = s.laneQuota(priority)
+		lanes = append(lanes, lane)
 	}
 
-	s.logger.Info("Message sending service stopped")
-	return nil
+	return lanes, nil
 }
 
-func (s *MessageProcessor) GetServiceStatus(ctx context.Context) (model.ServiceStatus, error) {
-	return s.statusRepo.GetServiceStatus(ctx)
+func (s *MessageProcessor) GetBreakers() []model.BreakerInfo {
+	return s.senders.Breakers()
+}
+
+func (s *MessageProcessor) GetLeaderInfo() model.LeaderInfo {
+	if s.elector == nil {
+		return model.LeaderInfo{InstanceID: s.cfg.Cluster.InstanceID}
+	}
+	return s.elector.Info()
+}
+
+// CreateMessage validates and queues a message. The default expiry of its
+// priority or tags is filled in when none is given.
+func (s *MessageProcessor) CreateMessage(ctx context.Context, req model.CreateMessageRequest) (*model.Message, error) {
+	now := time.Now()
+	if err := s.validateMessage(&req, now); err != nil {
+		return nil, err
+	}
+
+	msg := s.newMessage(req, now)
+	if err := s.repo.SaveMessage(ctx, &msg); err != nil {
+		return nil, fmt.Errorf("failed to save message: %w", err)
+	}
+
+	if msg.SendAt.After(now) {
+		s.scheduleChanged()
+	}
+
+	s.logger.Info("Message created",
+		zap.Uint("messageID", msg.ID),
+		zap.String("priority", string(msg.Priority)),
+		zap.Time("sendAt", msg.SendAt))
+	return &msg, nil
+}
+
+// newMessage builds the message to queue for a validated request. A message
+// without an expiry gets the TTL of its tags or priority. The send and expiry
+// times are converted to UTC, since the timestamp columns drop the offset.
+func (s *MessageProcessor) newMessage(req model.CreateMessageRequest, now time.Time) model.Message {
+	msg := model.Message{
+		Content:   req.Content,
+		Recipient: req.Recipient,
+		Priority:  req.Priority,
+		Tags:      req.Tags,
+		Metadata:  req.Metadata,
+		SendAt:    req.SendAt.UTC(),
+		ExpiresAt: req.ExpiresAt.UTC(),
+	}
+	if msg.ExpiresAt.IsZero() {
+		msg.CreatedAt = now
+		msg.ExpiresAt = s.expiresAt(msg).UTC()
+	}
+	return msg
+}
+
+func (s *MessageProcessor) GetMessage(ctx context.Context, id uint) (*model.MessageDetail, error) {
+	msg, err := s.repo.GetMessageByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get message: %w", err)
+	}
+	if msg == nil {
+		return nil, ErrMessageNotFound
+	}
+
+	return s.messageDetail(ctx, msg)
+}
+
+// GetMessageByExternalID finds a message by the ID its provider returned. The
+// Redis cache of sent messages is tried first, and a message found in the
+// database is cached for the next lookup.
+func (s *MessageProcessor) GetMessageByExternalID(ctx context.Context, messageID string) (*model.MessageDetail, error) {
+	id, err := s.cacheRepo.GetCachedMessageID(ctx, messageID)
+	if err != nil {
+		s.logger.Warn("Failed to look up cached message", zap.Error(err), zap.String("externalID", messageID))
+	}
+
+	var msg *model.Message
+	if id != 0 {
+		if msg, err = s.repo.GetMessageByID(ctx, id); err != nil {
+			return nil, fmt.Errorf("failed to get message: %w", err)
+		}
+		// The cached ID may point to a row that was deleted since.
+		if msg != nil && msg.MessageID != messageID {
+			msg = nil
+		}
+	}
+
+	if msg == nil {
+		if msg, err = s.repo.GetMessageByExternalID(ctx, messageID); err != nil {
+			return nil, fmt.Errorf("failed to get message by external ID: %w", err)
+		}
+		if msg == nil {
+			return nil, ErrMessageNotFound
+		}
+
+		if !msg.SentAt.IsZero() {
+			if err := s.cacheRepo.CacheMessageSent(ctx, messageID, msg.ID, msg.SentAt); err != nil {
+				s.logger.Warn("Failed to cache sent message", zap.Error(err), zap.String("externalID", messageID))
+			}
+		}
+	}
+
+	return s.messageDetail(ctx, msg)
+}
+
+func (s *MessageProcessor) messageDetail(ctx context.Context, msg *model.Message) (*model.MessageDetail, error) {
+	history, err := s.repo.GetStatusHistory(ctx, msg.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get status history: %w", err)
+	}
+
+	return &model.MessageDetail{
+		Message: *msg,
+		History: history,
+	}, nil
 }
 
 func (s *MessageProcessor) GetSentMessages(ctx context.Context, page, limit int) (*model.SentMessagesResponse, error) {
@@ -133,15 +380,93 @@ func (s *MessageProcessor) GetSentMessages(ctx context.Context, page, limit int)
 	}, nil
 }
 
-func (s *MessageProcessor) processMessages(ctx context.Context) {
-	s.logger.Debug("Processing messages")
+func (s *MessageProcessor) GetDeadMessages(ctx context.Context, page, limit int) (*model.DeadMessagesResponse, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	messages, total, err := s.repo.GetDeadMessages(ctx, page, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get dead messages: %w", err)
+	}
+
+	return &model.DeadMessagesResponse{
+		Messages: messages,
+		Count:    total,
+	}, nil
+}
 
-	messages, err := s.repo.GetUnsentMessages(ctx, s.cfg.Message.BatchSize)
+func (s *MessageProcessor) RequeueDeadMessage(ctx context.Context, id uint) error {
+	requeued, err := s.repo.RequeueDeadMessages(ctx, []uint{id})
 	if err != nil {
-		s.logger.Error("Failed to get unsent messages", zap.Error(err))
+		return fmt.Errorf("failed to requeue dead message: %w", err)
+	}
+
+	if requeued == 0 {
+		return ErrMessageNotFound
+	}
+
+	s.logger.Info("Dead message requeued", zap.Uint("messageID", id))
+	return nil
+}
+
+func (s *MessageProcessor) RequeueDeadMessages(ctx context.Context, ids []uint) (int64, error) {
+	requeued, err := s.repo.RequeueDeadMessages(ctx, ids)
+	if err != nil {
+		return 0, fmt.Errorf("failed to requeue dead messages: %w", err)
+	}
+
+	s.logger.Info("Dead messages requeued", zap.Int64("count", requeued))
+	return requeued, nil
+}
+
+func (s *MessageProcessor) RequeueAllDeadMessages(ctx context.Context) (int64, error) {
+	requeued, err := s.repo.RequeueAllDeadMessages(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to requeue all dead messages: %w", err)
+	}
+
+	s.logger.Warn("Every dead message requeued", zap.Int64("count", requeued))
+	return requeued, nil
+}
+
+// processMessages runs one tick. ctx governs claiming: once it is cancelled
+// no new messages are claimed and claimed messages that have not been sent
+// yet are handed back to the queue. sendCtx governs in-flight sends and
+// outlives ctx by the drain deadline so that their results are recorded.
+func (s *MessageProcessor) processMessages(ctx, sendCtx context.Context) {
+	if s.elector != nil && !s.elector.IsLeader() {
+		s.logger.Debug("Skipping tick, this instance is not the leader")
 		return
 	}
 
+	// Stale claims and expired messages are swept even while sends are held
+	// back, since that is when queued messages go stale.
+	released, err := s.repo.ReleaseExpiredClaims(ctx)
+	if err != nil {
+		s.logger.Error("Failed to release expired claims", zap.Error(err))
+	} else if released > 0 {
+		s.logger.Warn("Released messages with expired claims", zap.Int64("count", released))
+	}
+
+	expired, err := s.repo.ExpireMessages(ctx)
+	if err != nil {
+		s.logger.Error("Failed to expire messages", zap.Error(err))
+	} else if expired > 0 {
+		s.logger.Warn("Expired messages that were not sent in time", zap.Int64("count", expired))
+	}
+
+	if !s.senders.Available() {
+		s.logger.Debug("Skipping tick, every provider is held back by its circuit breaker or throttle")
+		return
+	}
+
+	s.logger.Debug("Processing messages")
+
+	messages := s.claimLanes(ctx)
 	if len(messages) == 0 {
 		s.logger.Debug("No unsent messages found")
 		return
@@ -149,77 +474,267 @@ func (s *MessageProcessor) processMessages(ctx context.Context) {
 
 	s.logger.Debug("Found unsent messages", zap.Int("count", len(messages)))
 
-	for _, msg := range messages {
-		if msg.MessageID != "" {
-			sent, err := s.cacheRepo.IsMessageSent(ctx, msg.MessageID)
-			if err != nil {
-				s.logger.Error("Failed to check if message is sent", zap.Error(err), zap.Uint("messageID", msg.ID))
-				continue
-			}
-			if sent {
-				s.logger.Debug("Message already sent according to cache", zap.Uint("messageID", msg.ID))
-				continue
-			}
-		}
+	s.dispatch(ctx, sendCtx, messages)
+}
+
+// claimLanes claims the batch of a tick lane by lane, from the highest
+// priority to the lowest. Every lane claims up to its own quota, so a backlog
+// in one lane never starves the others, and quota a lane leaves unused is
+// passed on to the next one.
+func (s *MessageProcessor) claimLanes(ctx context.Context) []model.Message {
+	var messages []model.Message
 
-		messageID, err := s.sendMessage(ctx, msg)
+	spare := 0
+	for _, priority := range model.Priorities {
+		limit := s.laneQuota(priority) + spare
+
+		claimed, err := s.repo.ClaimMessages(ctx, s.cfg.Cluster.InstanceID, priority, limit, s.leaseDuration())
 		if err != nil {
-			s.logger.Error("Failed to send message", zap.Error(err), zap.Uint("messageID", msg.ID))
+			s.logger.Error("Failed to claim unsent messages", zap.Error(err), zap.String("priority", string(priority)))
+			spare = 0
 			continue
 		}
 
-		sentAt := time.Now()
-		if err := s.repo.MarkMessageAsSent(ctx, msg.ID, messageID, sentAt); err != nil {
-			s.logger.Error("Failed to mark message as sent", zap.Error(err), zap.Uint("messageID", msg.ID))
-			continue
+		spare = limit - len(claimed)
+		if spare < 0 {
+			spare = 0
 		}
+		messages = append(messages, claimed...)
+	}
 
-		if err := s.cacheRepo.CacheMessageSent(ctx, messageID, sentAt); err != nil {
-			s.logger.Error("Failed to cache sent message", zap.Error(err), zap.String("messageID", messageID))
-		} else {
-			s.logger.Info("Message successfully cached",
-				zap.String("messageID", messageID),
-				zap.Time("sentAt", sentAt),
-				zap.Uint("msgID", msg.ID))
-		}
+	return messages
+}
 
-		s.logger.Info("Message sent successfully", zap.Uint("messageID", msg.ID), zap.String("externalID", messageID))
+// dispatch sends a claimed batch on a bounded pool of workers. Messages to the
+// same recipient are handled by a single worker in claim order, so they are
+// never reordered relative to each other. Once one of them is handed back to
+// the queue, the ones after it are released too instead of overtaking it.
+func (s *MessageProcessor) dispatch(ctx, sendCtx context.Context, messages []model.Message) {
+	groups := groupByRecipient(messages)
+
+	queue := make(chan []model.Message, len(groups))
+	for _, group := range groups {
+		queue <- group
+	}
+	close(queue)
+
+	workers := s.workerCount()
+	if workers > len(groups) {
+		workers = len(groups)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for group := range queue {
+				held := false
+				for _, msg := range group {
+					if ctx.Err() != nil || sendCtx.Err() != nil {
+						s.releaseMessage(context.WithoutCancel(sendCtx), msg, "processor draining")
+						continue
+					}
+					if held {
+						s.releaseMessage(context.WithoutCancel(sendCtx), msg, "an earlier message to the recipient is pending")
+						continue
+					}
+
+					held = !s.processMessage(sendCtx, msg)
+				}
+			}
+		}()
 	}
+
+	wg.Wait()
 }
 
-func (s *MessageProcessor) sendMessage(ctx context.Context, msg model.Message) (string, error) {
-	payload := map[string]interface{}{
-		"content":   msg.Content,
-		"recipient": msg.Recipient,
+func groupByRecipient(messages []model.Message) [][]model.Message {
+	index := make(map[string]int)
+	var groups [][]model.Message
+
+	for _, msg := range messages {
+		i, ok := index[msg.Recipient]
+		if !ok {
+			i = len(groups)
+			index[msg.Recipient] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], msg)
 	}
 
-	jsonData, err := json.Marshal(payload)
+	return groups
+}
+
+// processMessage sends a claimed message and records the outcome. Results are
+// recorded even if ctx is cancelled mid-send, so a message that reached the
+// provider is never left without its status. It returns false when the
+// message is handed back to the queue for a later attempt.
+func (s *MessageProcessor) processMessage(ctx context.Context, msg model.Message) bool {
+	recordCtx := context.WithoutCancel(ctx)
+
+	// The lease is taken for the whole batch, so it is renewed before every
+	// message and only has to outlast one send.
+	held, err := s.repo.RenewClaim(ctx, msg.ID, s.cfg.Cluster.InstanceID, s.leaseDuration())
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal message payload: %w", err)
+		s.logger.Error("Failed to renew claim", zap.Error(err), zap.Uint("messageID", msg.ID))
+		s.releaseMessage(recordCtx, msg, "claim renewal failed")
+		return false
+	}
+	if !held {
+		s.logger.Warn("Claim lost before sending, skipping message", zap.Uint("messageID", msg.ID))
+		return false
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.cfg.Webhook.URL, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return "", fmt.Errorf("failed to create webhook request: %w", err)
+	if expiresAt := s.expiresAt(msg); !expiresAt.IsZero() && !time.Now().Before(expiresAt) {
+		s.expireMessage(recordCtx, msg, expiresAt)
+		return true
 	}
 
-	req.Header.Set("Content-Type", "application/json")
+	if msg.MessageID != "" {
+		sent, err := s.cacheRepo.IsMessageSent(ctx, msg.MessageID)
+		if err != nil {
+			s.logger.Error("Failed to check if message is sent", zap.Error(err), zap.Uint("messageID", msg.ID))
+			s.releaseMessage(recordCtx, msg, "cache check failed")
+			return false
+		}
+		if sent {
+			s.logger.Debug("Message already sent according to cache", zap.Uint("messageID", msg.ID))
+			change := model.StatusChange{MessageID: msg.MessageID, Reason: "already sent according to cache"}
+			if err := s.transitionClaimed(recordCtx, msg.ID, model.MessageSent, change); err != nil {
+				s.logger.Error("Failed to mark message as sent", zap.Error(err), zap.Uint("messageID", msg.ID))
+			}
+			return true
+		}
+	}
+
+	slot, ok := s.acquireSendSlot(ctx, msg)
+	if !ok {
+		return false
+	}
 
-	resp, err := s.httpClient.Do(req)
+	result, err := s.deliver(ctx, msg)
+	if isDeferral(err) {
+		s.releaseSendSlot(recordCtx, msg, slot)
+		s.deferMessage(recordCtx, msg, err.Error(), s.senders.ResumeAt())
+		return false
+	}
 	if err != nil {
-		return "", fmt.Errorf("failed to send webhook request: %w", err)
+		return s.handleSendFailure(recordCtx, msg, result, err)
+	}
+
+	messageID := result.messageID
+	sentAt := time.Now()
+	change := model.StatusChange{
+		At:             sentAt,
+		MessageID:      messageID,
+		Provider:       result.provider,
+		FailedOverFrom: result.failedOverFrom,
+		SenderID:       result.senderID,
+	}
+	if err := s.transitionClaimed(recordCtx, msg.ID, model.MessageSent, change); err != nil {
+		s.logger.Error("Failed to mark message as sent", zap.Error(err), zap.Uint("messageID", msg.ID))
+		return true
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return "", fmt.Errorf("webhook returned non-success status: %d", resp.StatusCode)
+	if messageID == "" {
+		s.logger.Warn("Provider returned no message ID, skipping cache", zap.Uint("messageID", msg.ID))
+	} else if err := s.cacheRepo.CacheMessageSent(recordCtx, messageID, msg.ID, sentAt); err != nil {
+		s.logger.Error("Failed to cache sent message", zap.Error(err), zap.String("messageID", messageID))
+	} else {
+		s.logger.Info("Message successfully cached",
+			zap.String("messageID", messageID),
+			zap.Time("sentAt", sentAt),
+			zap.Uint("msgID", msg.ID))
 	}
 
-	requestID := resp.Header.Get("X-Request-Id")
-	if requestID != "" {
-		return requestID, nil
+	s.logger.Info("Message sent successfully",
+		zap.Uint("messageID", msg.ID),
+		zap.String("externalID", messageID),
+		zap.String("provider", result.provider),
+		zap.Strings("failedOverFrom", result.failedOverFrom))
+	return true
+}
+
+// transitionClaimed moves a message this instance has claimed out of
+// processing. It fails with repository.ErrClaimLost when the lease ran out and
+// the message was handed back or claimed by another worker in the meantime.
+func (s *MessageProcessor) transitionClaimed(ctx context.Context, id uint, to model.MessageStatus, change model.StatusChange) error {
+	change.ClaimedBy = s.cfg.Cluster.InstanceID
+	return s.repo.TransitionMessage(ctx, id, to, change)
+}
+
+// releaseMessage hands a claimed message back to the queue. The claim does not
+// count as a delivery attempt.
+func (s *MessageProcessor) releaseMessage(ctx context.Context, msg model.Message, reason string) {
+	if err := s.transitionClaimed(ctx, msg.ID, model.MessageQueued, model.StatusChange{Reason: reason}); err != nil {
+		s.logger.Error("Failed to release message", zap.Error(err), zap.Uint("messageID", msg.ID))
+	}
+}
+
+// deferMessage hands a claimed message back to the queue until the given time
+// without counting the claim as a delivery attempt.
+func (s *MessageProcessor) deferMessage(ctx context.Context, msg model.Message, reason string, until time.Time) {
+	change := model.StatusChange{Reason: reason, NextAttemptAt: until}
+	if err := s.transitionClaimed(ctx, msg.ID, model.MessageQueued, change); err != nil {
+		s.logger.Error("Failed to defer message", zap.Error(err), zap.Uint("messageID", msg.ID))
+		return
+	}
+
+	s.logger.Debug("Message deferred",
+		zap.Uint("messageID", msg.ID),
+		zap.String("reason", reason),
+		zap.Time("until", until))
+}
+
+// handleSendFailure records a failed delivery attempt. The message is
+// scheduled for another attempt with exponential backoff until it runs out of
+// attempts, after which it is moved to the dead state. A message the provider
+// accepted without an ID is moved there right away, since retrying it would
+// send it again. It returns true when the message is dead.
+func (s *MessageProcessor) handleSendFailure(ctx context.Context, msg model.Message, result delivery, sendErr error) bool {
+	attempts := msg.Attempts
+	now := time.Now()
+
+	if attempts >= s.maxAttempts() || isFinal(sendErr) {
+		change := model.StatusChange{
+			At:             now,
+			Reason:         sendErr.Error(),
+			Provider:       result.provider,
+			FailedOverFrom: result.failedOverFrom,
+		}
+		if err := s.transitionClaimed(ctx, msg.ID, model.MessageDead, change); err != nil {
+			s.logger.Error("Failed to mark message as dead", zap.Error(err), zap.Uint("messageID", msg.ID))
+			return false
+		}
+
+		s.logger.Warn("Message moved to dead state",
+			zap.Error(sendErr),
+			zap.Uint("messageID", msg.ID),
+			zap.String("provider", result.provider),
+			zap.Int("attempts", attempts))
+		return true
+	}
+
+	nextAttemptAt := now.Add(s.retryDelay(attempts))
+	change := model.StatusChange{
+		At:             now,
+		Reason:         sendErr.Error(),
+		NextAttemptAt:  nextAttemptAt,
+		Provider:       result.provider,
+		FailedOverFrom: result.failedOverFrom,
+	}
+	if err := s.transitionClaimed(ctx, msg.ID, model.MessageFailed, change); err != nil {
+		s.logger.Error("Failed to record failed attempt", zap.Error(err), zap.Uint("messageID", msg.ID))
+		return false
 	}
 
-	uniqueID := fmt.Sprintf("webhook-%d-%d", msg.ID, time.Now().UnixNano())
-	return uniqueID, nil
+	s.logger.Error("Failed to send message",
+		zap.Error(sendErr),
+		zap.Uint("messageID", msg.ID),
+		zap.String("provider", result.provider),
+		zap.Int("attempts", attempts),
+		zap.Time("nextAttemptAt", nextAttemptAt))
+	return false
 }
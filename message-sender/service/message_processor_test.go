@@ -2,69 +2,375 @@ package service
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
 	"testing"
 	"time"
 
 	"message-sender/config"
 	"message-sender/model"
+	"message-sender/repository"
+	"message-sender/sender"
 
 	"go.uber.org/zap/zaptest"
 )
 
 type MockRepository struct {
-	messages         []model.Message
-	markAsSentCalled bool
-	messageID        string
+	mu                 sync.Mutex
+	messages           []model.Message
+	markAsSentCalled   bool
+	messageID          string
+	provider           string
+	failedOverFrom     []string
+	markAsFailedCalled bool
+	markAsDeadCalled   bool
+	lastError          string
+	nextAttemptAt      time.Time
+	transitions        []model.MessageStatus
+	claimedBy          string
+	claimLimits        []int
+	ticks              int
+	backlog            map[model.MessagePriority]model.LaneBacklog
+	releaseCalled      bool
+	nextScheduledAt    time.Time
+	saved              []model.Message
+	importJob          model.ImportJob
+	importErrors       []model.ImportRowError
+	lookups            []string
+	filter             model.MessageFilter
+	exportErr          error
+	lostClaims         map[uint]bool
+	expirySweeps       int
 }
 
-func (m *MockRepository) GetUnsentMessages(ctx context.Context, limit int) ([]model.Message, error) {
-	return m.messages, nil
+func (m *MockRepository) ClaimMessages(ctx context.Context, workerID string, priority model.MessagePriority, limit int, lease time.Duration) ([]model.Message, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.claimedBy = workerID
+	m.claimLimits = append(m.claimLimits, limit)
+
+	var claimed []model.Message
+	for _, msg := range m.messages {
+		if msg.Priority == "" {
+			msg.Priority = model.PriorityNormal
+		}
+		if msg.Priority != priority {
+			continue
+		}
+		msg.Status = model.MessageProcessing
+		msg.Attempts++
+		msg.ClaimedBy = workerID
+		claimed = append(claimed, msg)
+	}
+	return claimed, nil
+}
+
+func (m *MockRepository) LaneBacklog(ctx context.Context) (map[model.MessagePriority]model.LaneBacklog, error) {
+	return m.backlog, nil
+}
+
+// NextScheduledAt reports the scheduled time once, as if the message was
+// claimed on the tick it woke up.
+func (m *MockRepository) NextScheduledAt(ctx context.Context) (time.Time, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	due := m.nextScheduledAt
+	m.nextScheduledAt = time.Time{}
+	return due, nil
 }
 
-func (m *MockRepository) MarkMessageAsSent(ctx context.Context, id uint, messageID string, sentAt time.Time) error {
-	m.markAsSentCalled = true
-	m.messageID = messageID
+func (m *MockRepository) tickCount() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.ticks
+}
+
+func (m *MockRepository) ExpireMessages(ctx context.Context) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.expirySweeps++
+	return 0, nil
+}
+
+func (m *MockRepository) RenewClaim(ctx context.Context, id uint, workerID string, lease time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return !m.lostClaims[id], nil
+}
+
+func (m *MockRepository) ReleaseExpiredClaims(ctx context.Context) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.ticks++
+	m.releaseCalled = true
+	return 0, nil
+}
+
+func (m *MockRepository) TransitionMessage(ctx context.Context, id uint, to model.MessageStatus, change model.StatusChange) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if change.ClaimedBy != "" && change.ClaimedBy != m.claimedBy {
+		return repository.ErrClaimLost
+	}
+	m.transitions = append(m.transitions, to)
+	if change.Provider != "" {
+		m.provider = change.Provider
+		m.failedOverFrom = change.FailedOverFrom
+	}
+	switch to {
+	case model.MessageSent:
+		m.markAsSentCalled = true
+		m.messageID = change.MessageID
+	case model.MessageFailed:
+		m.markAsFailedCalled = true
+		m.lastError = change.Reason
+		m.nextAttemptAt = change.NextAttemptAt
+	case model.MessageDead:
+		m.markAsDeadCalled = true
+		m.lastError = change.Reason
+	case model.MessageQueued:
+		m.nextAttemptAt = change.NextAttemptAt
+	}
 	return nil
 }
 
+func (m *MockRepository) ListMessages(ctx context.Context, filter model.MessageFilter, cursor model.PageCursor, limit int) ([]model.Message, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// messages are kept in ID order, oldest first
+	var page []model.Message
+	if cursor.Backward {
+		for _, msg := range m.messages {
+			if msg.ID > cursor.ID && len(page) < limit {
+				page = append([]model.Message{msg}, page...)
+			}
+		}
+		return page, nil
+	}
+
+	for i := len(m.messages) - 1; i >= 0 && len(page) < limit; i-- {
+		if cursor.ID == 0 || m.messages[i].ID < cursor.ID {
+			page = append(page, m.messages[i])
+		}
+	}
+	return page, nil
+}
+
+func (m *MockRepository) ExportMessages(ctx context.Context, filter model.MessageFilter, fetchSize int, fn func(model.Message) error) error {
+	m.mu.Lock()
+	messages := append([]model.Message(nil), m.messages...)
+	m.filter = filter
+	m.mu.Unlock()
+
+	if m.exportErr != nil {
+		return m.exportErr
+	}
+	for _, msg := range messages {
+		if err := fn(msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *MockRepository) CountMessages(ctx context.Context, filter model.MessageFilter) (int, error) {
+	return len(m.messages), nil
+}
+
+func (m *MockRepository) GetDeadMessages(ctx context.Context, page, limit int) ([]model.Message, int, error) {
+	return []model.Message{}, 0, nil
+}
+
+func (m *MockRepository) RequeueDeadMessages(ctx context.Context, ids []uint) (int64, error) {
+	return int64(len(ids)), nil
+}
+
+func (m *MockRepository) RequeueAllDeadMessages(ctx context.Context) (int64, error) {
+	return 0, nil
+}
+
 func (m *MockRepository) GetSentMessages(ctx context.Context, page, limit int) ([]model.Message, int, error) {
 	return []model.Message{}, 0, nil
 }
 
 func (m *MockRepository) GetMessageByID(ctx context.Context, id uint) (*model.Message, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.lookups = append(m.lookups, "id")
+	for _, msg := range m.messages {
+		if msg.ID == id {
+			return &msg, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *MockRepository) GetMessageByExternalID(ctx context.Context, messageID string) (*model.Message, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.lookups = append(m.lookups, "externalID")
+	for _, msg := range m.messages {
+		if msg.MessageID == messageID {
+			return &msg, nil
+		}
+	}
 	return nil, nil
 }
 
+func (m *MockRepository) GetStatusHistory(ctx context.Context, id uint) ([]model.StatusHistoryEntry, error) {
+	return []model.StatusHistoryEntry{{ToStatus: model.MessageQueued}}, nil
+}
+
 func (m *MockRepository) SaveMessage(ctx context.Context, message *model.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	message.ID = uint(len(m.saved) + 1)
+	message.Status = model.MessageQueued
+	m.saved = append(m.saved, *message)
+	return nil
+}
+
+func (m *MockRepository) SaveMessages(ctx context.Context, messages []model.Message) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.saved = append(m.saved, messages...)
+	return int64(len(messages)), nil
+}
+
+func (m *MockRepository) UpdatePendingMessage(ctx context.Context, id uint, update func(*model.Message) error) (*model.Message, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, msg := range m.messages {
+		if msg.ID != id {
+			continue
+		}
+		if !msg.Status.CanTransitionTo(model.MessageCancelled) {
+			return nil, repository.ErrMessageNotPending
+		}
+		if err := update(&msg); err != nil {
+			return nil, err
+		}
+		m.messages[i] = msg
+		return &msg, nil
+	}
+	return nil, repository.ErrMessageNotFound
+}
+
+func (m *MockRepository) CancelPendingMessage(ctx context.Context, id uint, reason string) (*model.Message, error) {
+	return m.UpdatePendingMessage(ctx, id, func(msg *model.Message) error {
+		msg.Status = model.MessageCancelled
+		return nil
+	})
+}
+
+func (m *MockRepository) CreateImportJob(ctx context.Context, job *model.ImportJob) error {
+	job.ID = 1
+	m.importJob = *job
+	return nil
+}
+
+func (m *MockRepository) UpdateImportJob(ctx context.Context, job *model.ImportJob) error {
+	m.importJob = *job
 	return nil
 }
 
+func (m *MockRepository) GetImportJob(ctx context.Context, id uint) (*model.ImportJob, error) {
+	if m.importJob.ID != id {
+		return nil, nil
+	}
+	job := m.importJob
+	return &job, nil
+}
+
+func (m *MockRepository) SaveImportErrors(ctx context.Context, jobID uint, rowErrors []model.ImportRowError) error {
+	m.importErrors = append(m.importErrors, rowErrors...)
+	return nil
+}
+
+func (m *MockRepository) GetImportErrors(ctx context.Context, jobID uint, page, limit int) ([]model.ImportRowError, int, error) {
+	return m.importErrors, len(m.importErrors), nil
+}
+
 type MockStatusRepository struct {
-	status model.ServiceStatus
+	mu        sync.Mutex
+	status    model.ServiceStatus
+	published []model.ActionType
+	commands  chan model.ActionType
 }
 
 func (m *MockStatusRepository) GetServiceStatus(ctx context.Context) (model.ServiceStatus, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	return m.status, nil
 }
 
 func (m *MockStatusRepository) SetServiceStatus(ctx context.Context, status model.ServiceStatus) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.status = status
 	return nil
 }
 
+func (m *MockStatusRepository) PublishControl(ctx context.Context, action model.ActionType) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.published = append(m.published, action)
+	return nil
+}
+
+func (m *MockStatusRepository) SubscribeControl(ctx context.Context) (<-chan model.ActionType, error) {
+	if m.commands == nil {
+		m.commands = make(chan model.ActionType)
+	}
+	return m.commands, nil
+}
+
 type MockCacheRepository struct {
+	mu             sync.Mutex
 	cachedMessages map[string]time.Time
+	cachedIDs      map[string]uint
 }
 
-func (m *MockCacheRepository) CacheMessageSent(ctx context.Context, messageID string, sentAt time.Time) error {
+func (m *MockCacheRepository) CacheMessageSent(ctx context.Context, messageID string, id uint, sentAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	if m.cachedMessages == nil {
 		m.cachedMessages = make(map[string]time.Time)
+		m.cachedIDs = make(map[string]uint)
 	}
 	m.cachedMessages[messageID] = sentAt
+	m.cachedIDs[messageID] = id
 	return nil
 }
 
+func (m *MockCacheRepository) GetCachedMessageID(ctx context.Context, messageID string) (uint, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.cachedIDs[messageID], nil
+}
+
 func (m *MockCacheRepository) IsMessageSent(ctx context.Context, messageID string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	if m.cachedMessages == nil {
 		return false, nil
 	}
@@ -76,6 +382,17 @@ func (m *MockCacheRepository) GetCachedSentMessages(ctx context.Context) (map[st
 	return m.cachedMessages, nil
 }
 
+// newTestSenders registers the webhook in cfg as the only provider.
+func newTestSenders(t *testing.T, cfg *config.Config) *sender.Registry {
+	t.Helper()
+
+	senders, err := sender.NewRegistry("", sender.NewWebhookSender("webhook", cfg.Webhook))
+	if err != nil {
+		t.Fatalf("Failed to create senders: %v", err)
+	}
+	return senders
+}
+
 func TestMessageProcessor_GetServiceStatus(t *testing.T) {
 	mockRepo := &MockRepository{}
 	mockStatusRepo := &MockStatusRepository{status: model.StatusRunning}
@@ -83,7 +400,7 @@ func TestMessageProcessor_GetServiceStatus(t *testing.T) {
 	logger := zaptest.NewLogger(t)
 	cfg := &config.Config{}
 
-	processor := NewMessageProcessor(mockRepo, mockStatusRepo, mockCacheRepo, logger, cfg)
+	processor := NewMessageProcessor(mockRepo, mockStatusRepo, mockCacheRepo, newTestSenders(t, cfg), logger, cfg)
 
 	status, err := processor.GetServiceStatus(context.Background())
 	if err != nil {
@@ -107,7 +424,7 @@ func TestMessageProcessor_StartStopService(t *testing.T) {
 		},
 	}
 
-	processor := NewMessageProcessor(mockRepo, mockStatusRepo, mockCacheRepo, logger, cfg)
+	processor := NewMessageProcessor(mockRepo, mockStatusRepo, mockCacheRepo, newTestSenders(t, cfg), logger, cfg)
 
 	err := processor.StartService(context.Background())
 	if err != nil {
@@ -128,4 +445,383 @@ func TestMessageProcessor_StartStopService(t *testing.T) {
 	if status != model.StatusStopped {
 		t.Errorf("Expected status %s after stop, got %s", model.StatusStopped, status)
 	}
+
+	// A second cycle must not panic on the channel closed by the first stop
+	if err := processor.StartService(context.Background()); err != nil {
+		t.Fatalf("Expected no error on restart, got %v", err)
+	}
+	if !processor.isLoopRunning() {
+		t.Error("Expected loop to run after restart")
+	}
+	if err := processor.StopService(context.Background()); err != nil {
+		t.Fatalf("Expected no error on second stop, got %v", err)
+	}
+	if processor.isLoopRunning() {
+		t.Error("Expected loop to be stopped after second stop")
+	}
+
+	expected := []model.ActionType{model.ActionStart, model.ActionStop, model.ActionStart, model.ActionStop}
+	if len(mockStatusRepo.published) != len(expected) {
+		t.Fatalf("Expected published commands %v, got %v", expected, mockStatusRepo.published)
+	}
+	for i, action := range expected {
+		if mockStatusRepo.published[i] != action {
+			t.Errorf("Expected command %d to be %s, got %s", i, action, mockStatusRepo.published[i])
+		}
+	}
+}
+
+func TestMessageProcessor_ProcessMessages_RetriesFailedSend(t *testing.T) {
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer webhook.Close()
+
+	mockRepo := &MockRepository{
+		messages: []model.Message{{ID: 1, Content: "hello", Recipient: "+905500000000", Attempts: 1}},
+	}
+	logger := zaptest.NewLogger(t)
+	cfg := &config.Config{
+		Message: config.MessageConfig{
+			MaxAttempts:    3,
+			RetryBaseDelay: time.Minute,
+			RetryMaxDelay:  time.Hour,
+		},
+		Webhook: config.WebhookConfig{URL: webhook.URL, Timeout: time.Second},
+	}
+
+	processor := NewMessageProcessor(mockRepo, &MockStatusRepository{}, &MockCacheRepository{}, newTestSenders(t, cfg), logger, cfg)
+	processor.processMessages(context.Background(), context.Background())
+
+	if mockRepo.markAsSentCalled {
+		t.Error("Expected message not to be marked as sent")
+	}
+	if !mockRepo.markAsFailedCalled {
+		t.Fatal("Expected failed attempt to be recorded")
+	}
+	if mockRepo.lastError == "" {
+		t.Error("Expected failure reason to be recorded")
+	}
+	if delay := time.Until(mockRepo.nextAttemptAt); delay < time.Minute-time.Second || delay > 2*time.Minute {
+		t.Errorf("Expected next attempt in [1m, 2m], got %s", delay)
+	}
+}
+
+func TestMessageProcessor_ProcessMessages_MovesExhaustedMessageToDead(t *testing.T) {
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer webhook.Close()
+
+	mockRepo := &MockRepository{
+		messages: []model.Message{{ID: 1, Content: "hello", Recipient: "+905500000000", Attempts: 2}},
+	}
+	logger := zaptest.NewLogger(t)
+	cfg := &config.Config{
+		Message: config.MessageConfig{MaxAttempts: 3},
+		Webhook: config.WebhookConfig{URL: webhook.URL, Timeout: time.Second},
+	}
+
+	processor := NewMessageProcessor(mockRepo, &MockStatusRepository{}, &MockCacheRepository{}, newTestSenders(t, cfg), logger, cfg)
+	processor.processMessages(context.Background(), context.Background())
+
+	if !mockRepo.markAsDeadCalled {
+		t.Fatal("Expected message to be moved to dead state")
+	}
+	if mockRepo.markAsFailedCalled {
+		t.Error("Expected no further retry to be scheduled")
+	}
+}
+
+func TestMessageProcessor_ProcessMessages_TransitionsSentMessage(t *testing.T) {
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte(`{"message":"Accepted","messageId":"provider-id"}`))
+	}))
+	defer webhook.Close()
+
+	mockRepo := &MockRepository{
+		messages: []model.Message{{ID: 1, Content: "hello", Recipient: "+905500000000", Status: model.MessageQueued}},
+	}
+	mockCacheRepo := &MockCacheRepository{}
+	cfg := &config.Config{
+		Webhook: config.WebhookConfig{URL: webhook.URL, Timeout: time.Second, MessageIDPath: "messageId"},
+		Cluster: config.ClusterConfig{InstanceID: "worker-1"},
+	}
+
+	processor := NewMessageProcessor(mockRepo, &MockStatusRepository{}, mockCacheRepo, newTestSenders(t, cfg), zaptest.NewLogger(t), cfg)
+	processor.processMessages(context.Background(), context.Background())
+
+	if !mockRepo.releaseCalled {
+		t.Error("Expected expired claims to be released before claiming")
+	}
+	if mockRepo.claimedBy != "worker-1" {
+		t.Errorf("Expected messages to be claimed by worker-1, got %q", mockRepo.claimedBy)
+	}
+
+	expected := []model.MessageStatus{model.MessageSent}
+	if len(mockRepo.transitions) != len(expected) {
+		t.Fatalf("Expected transitions %v, got %v", expected, mockRepo.transitions)
+	}
+	for i, status := range expected {
+		if mockRepo.transitions[i] != status {
+			t.Errorf("Expected transition %d to be %s, got %s", i, status, mockRepo.transitions[i])
+		}
+	}
+
+	if mockRepo.messageID != "provider-id" {
+		t.Errorf("Expected message ID provider-id, got %s", mockRepo.messageID)
+	}
+	if mockRepo.provider != "webhook" {
+		t.Errorf("Expected provider webhook, got %s", mockRepo.provider)
+	}
+	if _, ok := mockCacheRepo.cachedMessages["provider-id"]; !ok {
+		t.Error("Expected sent message to be cached")
+	}
+}
+
+func TestMessageProcessor_RetryDelay(t *testing.T) {
+	cfg := &config.Config{
+		Message: config.MessageConfig{
+			RetryBaseDelay: time.Second,
+			RetryMaxDelay:  10 * time.Second,
+		},
+	}
+	processor := NewMessageProcessor(&MockRepository{}, &MockStatusRepository{}, &MockCacheRepository{}, newTestSenders(t, cfg), zaptest.NewLogger(t), cfg)
+
+	tests := []struct {
+		attempts int
+		max      time.Duration
+	}{
+		{attempts: 1, max: time.Second},
+		{attempts: 2, max: 2 * time.Second},
+		{attempts: 3, max: 4 * time.Second},
+		{attempts: 10, max: 10 * time.Second},
+	}
+
+	for _, tt := range tests {
+		for i := 0; i < 20; i++ {
+			delay := processor.retryDelay(tt.attempts)
+			if delay < tt.max/2 || delay > tt.max {
+				t.Errorf("attempts=%d: expected delay in [%s, %s], got %s", tt.attempts, tt.max/2, tt.max, delay)
+			}
+		}
+	}
+}
+
+func TestMessageProcessor_ProcessMessages_SendsInParallelPreservingRecipientOrder(t *testing.T) {
+	var mu sync.Mutex
+	var inFlight, maxInFlight int
+	received := make(map[string][]string)
+
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+
+		mu.Lock()
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		mu.Unlock()
+
+		time.Sleep(20 * time.Millisecond)
+
+		mu.Lock()
+		inFlight--
+		received[payload["recipient"]] = append(received[payload["recipient"]], payload["content"])
+		mu.Unlock()
+
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer webhook.Close()
+
+	mockRepo := &MockRepository{
+		messages: []model.Message{
+			{ID: 1, Content: "a1", Recipient: "+905500000000"},
+			{ID: 2, Content: "b1", Recipient: "+905500000001"},
+			{ID: 3, Content: "a2", Recipient: "+905500000000"},
+			{ID: 4, Content: "c1", Recipient: "+905500000002"},
+			{ID: 5, Content: "a3", Recipient: "+905500000000"},
+			{ID: 6, Content: "b2", Recipient: "+905500000001"},
+		},
+	}
+	cfg := &config.Config{
+		Message: config.MessageConfig{Workers: 3},
+		Webhook: config.WebhookConfig{URL: webhook.URL, Timeout: time.Second},
+	}
+
+	processor := NewMessageProcessor(mockRepo, &MockStatusRepository{}, &MockCacheRepository{}, newTestSenders(t, cfg), zaptest.NewLogger(t), cfg)
+	processor.processMessages(context.Background(), context.Background())
+
+	if maxInFlight < 2 {
+		t.Errorf("Expected messages to be sent in parallel, max in flight was %d", maxInFlight)
+	}
+	if maxInFlight > 3 {
+		t.Errorf("Expected at most 3 sends in flight, got %d", maxInFlight)
+	}
+
+	expected := map[string][]string{
+		"+905500000000": {"a1", "a2", "a3"},
+		"+905500000001": {"b1", "b2"},
+		"+905500000002": {"c1"},
+	}
+	for recipient, contents := range expected {
+		got := received[recipient]
+		if len(got) != len(contents) {
+			t.Fatalf("Expected %v for %s, got %v", contents, recipient, got)
+		}
+		for i := range contents {
+			if got[i] != contents[i] {
+				t.Errorf("Expected %v for %s, got %v", contents, recipient, got)
+				break
+			}
+		}
+	}
+
+	if len(mockRepo.transitions) != len(mockRepo.messages) {
+		t.Errorf("Expected %d transitions, got %d", len(mockRepo.messages), len(mockRepo.transitions))
+	}
+}
+
+func TestMessageProcessor_ClaimLanes(t *testing.T) {
+	mockRepo := &MockRepository{
+		messages: []model.Message{
+			{ID: 1, Content: "newsletter", Recipient: "+905500000000", Priority: model.PriorityBulk},
+			{ID: 2, Content: "receipt", Recipient: "+905500000001"},
+			{ID: 3, Content: "otp", Recipient: "+905500000002", Priority: model.PriorityHigh},
+		},
+	}
+	cfg := &config.Config{
+		Message:  config.MessageConfig{BatchSize: 10},
+		Priority: config.PriorityConfig{HighQuota: 2, NormalQuota: 3, BulkQuota: 1},
+	}
+
+	processor := NewMessageProcessor(mockRepo, &MockStatusRepository{}, &MockCacheRepository{}, newTestSenders(t, cfg), zaptest.NewLogger(t), cfg)
+	messages := processor.claimLanes(context.Background())
+
+	var ids []uint
+	for _, msg := range messages {
+		ids = append(ids, msg.ID)
+	}
+	if !reflect.DeepEqual(ids, []uint{3, 2, 1}) {
+		t.Errorf("Expected messages in lane order [3 2 1], got %v", ids)
+	}
+
+	// Quota left unused by a lane is passed on to the next one
+	if expected := []int{2, 4, 4}; !reflect.DeepEqual(mockRepo.claimLimits, expected) {
+		t.Errorf("Expected claim limits %v, got %v", expected, mockRepo.claimLimits)
+	}
+}
+
+func TestMessageProcessor_LaneQuota(t *testing.T) {
+	tests := []struct {
+		name      string
+		batchSize int
+		quotas    config.PriorityConfig
+		expected  []int
+	}{
+		{
+			name:      "no quotas split the batch size",
+			batchSize: 10,
+			expected:  []int{4, 3, 3},
+		},
+		{
+			name:      "unset lanes share what the quotas leave",
+			batchSize: 10,
+			quotas:    config.PriorityConfig{HighQuota: 5},
+			expected:  []int{5, 3, 2},
+		},
+		{
+			name:      "lanes left without a share still move",
+			batchSize: 10,
+			quotas:    config.PriorityConfig{HighQuota: 8, NormalQuota: 4},
+			expected:  []int{8, 4, 1},
+		},
+		{
+			name:      "batch smaller than the lanes",
+			batchSize: 2,
+			quotas:    config.PriorityConfig{BulkQuota: 1},
+			expected:  []int{1, 1, 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{
+				Message:  config.MessageConfig{BatchSize: tt.batchSize},
+				Priority: tt.quotas,
+			}
+			processor := NewMessageProcessor(&MockRepository{}, &MockStatusRepository{}, &MockCacheRepository{}, newTestSenders(t, cfg), zaptest.NewLogger(t), cfg)
+
+			var quotas []int
+			for _, priority := range model.Priorities {
+				quotas = append(quotas, processor.laneQuota(priority))
+			}
+			if !reflect.DeepEqual(quotas, tt.expected) {
+				t.Errorf("Expected lane quotas %v, got %v", tt.expected, quotas)
+			}
+		})
+	}
+}
+
+func TestMessageProcessor_GetLaneBacklog(t *testing.T) {
+	mockRepo := &MockRepository{
+		backlog: map[model.MessagePriority]model.LaneBacklog{
+			model.PriorityBulk: {Priority: model.PriorityBulk, Queued: 5, Due: 3},
+		},
+	}
+	cfg := &config.Config{
+		Message:  config.MessageConfig{BatchSize: 10},
+		Priority: config.PriorityConfig{BulkQuota: 1},
+	}
+
+	processor := NewMessageProcessor(mockRepo, &MockStatusRepository{}, &MockCacheRepository{}, newTestSenders(t, cfg), zaptest.NewLogger(t), cfg)
+	lanes, err := processor.GetLaneBacklog(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := []model.LaneBacklog{
+		{Priority: model.PriorityHigh, Quota: 5},
+		{Priority: model.PriorityNormal, Quota: 4},
+		{Priority: model.PriorityBulk, Queued: 5, Due: 3, Quota: 1},
+	}
+	if !reflect.DeepEqual(lanes, expected) {
+		t.Errorf("Expected lanes %+v, got %+v", expected, lanes)
+	}
+}
+
+func TestMessageProcessor_GetMessageByExternalID(t *testing.T) {
+	sentAt := time.Now()
+	mockRepo := &MockRepository{
+		messages: []model.Message{{ID: 7, Recipient: "+905500000000", MessageID: "provider-id", SentAt: sentAt}},
+	}
+	mockCacheRepo := &MockCacheRepository{}
+	cfg := &config.Config{}
+	processor := NewMessageProcessor(mockRepo, &MockStatusRepository{}, mockCacheRepo, newTestSenders(t, cfg), zaptest.NewLogger(t), cfg)
+
+	detail, err := processor.GetMessageByExternalID(context.Background(), "provider-id")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if detail.ID != 7 || len(detail.History) != 1 {
+		t.Errorf("Expected message 7 with its history, got %+v", detail)
+	}
+	if mockCacheRepo.cachedIDs["provider-id"] != 7 {
+		t.Errorf("Expected the message to be cached after a database lookup")
+	}
+
+	// The second lookup goes through the cache
+	mockRepo.lookups = nil
+	if _, err := processor.GetMessageByExternalID(context.Background(), "provider-id"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !reflect.DeepEqual(mockRepo.lookups, []string{"id"}) {
+		t.Errorf("Expected a lookup by ID only, got %v", mockRepo.lookups)
+	}
+
+	if _, err := processor.GetMessageByExternalID(context.Background(), "unknown"); !errors.Is(err, ErrMessageNotFound) {
+		t.Errorf("Expected ErrMessageNotFound, got %v", err)
+	}
 }
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"message-sender/model"
+	"message-sender/repository"
+)
+
+// UpdateMessage changes the content, recipient or schedule of a message that
+// is still queued or waiting for a retry, validating the result like a new
+// message. It returns ErrMessageNotPending once a worker has claimed the
+// message.
+func (s *MessageProcessor) UpdateMessage(ctx context.Context, id uint, req model.UpdateMessageRequest) (*model.Message, error) {
+	if req.IsEmpty() {
+		return nil, ErrEmptyUpdate
+	}
+
+	now := time.Now()
+	msg, err := s.repo.UpdatePendingMessage(ctx, id, func(msg *model.Message) error {
+		if req.Content != nil {
+			msg.Content = *req.Content
+		}
+		if req.Recipient != nil {
+			msg.Recipient = *req.Recipient
+		}
+		if req.SendAt != nil {
+			msg.SendAt = req.SendAt.UTC()
+		}
+		if req.ExpiresAt != nil {
+			msg.ExpiresAt = req.ExpiresAt.UTC()
+		}
+
+		return s.validateMessage(&model.CreateMessageRequest{
+			Content:   msg.Content,
+			Recipient: msg.Recipient,
+			SendAt:    msg.SendAt,
+			ExpiresAt: msg.ExpiresAt,
+			Priority:  msg.Priority,
+			Tags:      msg.Tags,
+			Metadata:  msg.Metadata,
+		}, now)
+	})
+	if err != nil {
+		return nil, pendingMessageError("update", err)
+	}
+
+	if req.SendAt != nil && msg.SendAt.After(now) {
+		s.scheduleChanged()
+	}
+
+	s.logger.Info("Message updated", zap.Uint("messageID", id))
+	return msg, nil
+}
+
+// CancelMessage withdraws a message that is still queued or waiting for a
+// retry. It returns ErrMessageNotPending once a worker has claimed the message.
+func (s *MessageProcessor) CancelMessage(ctx context.Context, id uint) (*model.Message, error) {
+	msg, err := s.repo.CancelPendingMessage(ctx, id, "cancelled through the API")
+	if err != nil {
+		return nil, pendingMessageError("cancel", err)
+	}
+
+	s.logger.Info("Message cancelled", zap.Uint("messageID", id))
+	return msg, nil
+}
+
+func pendingMessageError(action string, err error) error {
+	var verr *ValidationError
+	switch {
+	case errors.As(err, &verr):
+		return verr
+	case errors.Is(err, repository.ErrMessageNotFound):
+		return ErrMessageNotFound
+	case errors.Is(err, repository.ErrMessageNotPending):
+		return ErrMessageNotPending
+	}
+	return fmt.Errorf("failed to %s message: %w", action, err)
+}
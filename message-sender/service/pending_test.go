@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zaptest"
+
+	"message-sender/config"
+	"message-sender/model"
+)
+
+func TestMessageProcessor_UpdateMessage(t *testing.T) {
+	mockRepo := &MockRepository{
+		messages: []model.Message{
+			{ID: 1, Content: "hello", Recipient: "+905551111111", Status: model.MessageQueued, Priority: model.PriorityNormal},
+			{ID: 2, Content: "hello", Recipient: "+905551111111", Status: model.MessageProcessing},
+		},
+	}
+	cfg := &config.Config{}
+	processor := NewMessageProcessor(mockRepo, &MockStatusRepository{}, &MockCacheRepository{}, newTestSenders(t, cfg), zaptest.NewLogger(t), cfg)
+
+	content := "updated"
+	sendAt := time.Now().Add(time.Hour).In(time.FixedZone("TRT", 3*60*60))
+	msg, err := processor.UpdateMessage(context.Background(), 1, model.UpdateMessageRequest{Content: &content, SendAt: &sendAt})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if msg.Content != "updated" || !msg.SendAt.Equal(sendAt) || msg.SendAt.Location() != time.UTC || msg.Recipient != "+905551111111" {
+		t.Errorf("Expected content and schedule to change and the recipient to be kept, got %+v", msg)
+	}
+
+	recipient := "05551111111"
+	_, err = processor.UpdateMessage(context.Background(), 1, model.UpdateMessageRequest{Recipient: &recipient})
+	var verr *ValidationError
+	if !errors.As(err, &verr) || len(verr.Fields) != 1 || verr.Fields[0].Field != "recipient" {
+		t.Errorf("Expected a recipient validation error, got %v", err)
+	}
+	if mockRepo.messages[0].Recipient != "+905551111111" {
+		t.Errorf("Expected an invalid update not to be stored")
+	}
+
+	if _, err := processor.UpdateMessage(context.Background(), 2, model.UpdateMessageRequest{Content: &content}); !errors.Is(err, ErrMessageNotPending) {
+		t.Errorf("Expected ErrMessageNotPending for a claimed message, got %v", err)
+	}
+	if _, err := processor.UpdateMessage(context.Background(), 3, model.UpdateMessageRequest{Content: &content}); !errors.Is(err, ErrMessageNotFound) {
+		t.Errorf("Expected ErrMessageNotFound, got %v", err)
+	}
+	if _, err := processor.UpdateMessage(context.Background(), 1, model.UpdateMessageRequest{}); !errors.Is(err, ErrEmptyUpdate) {
+		t.Errorf("Expected ErrEmptyUpdate, got %v", err)
+	}
+}
+
+func TestMessageProcessor_CancelMessage(t *testing.T) {
+	mockRepo := &MockRepository{
+		messages: []model.Message{
+			{ID: 1, Content: "hello", Recipient: "+905551111111", Status: model.MessageQueued},
+			{ID: 2, Content: "hello", Recipient: "+905551111111", Status: model.MessageFailed},
+		},
+	}
+	cfg := &config.Config{}
+	processor := NewMessageProcessor(mockRepo, &MockStatusRepository{}, &MockCacheRepository{}, newTestSenders(t, cfg), zaptest.NewLogger(t), cfg)
+
+	msg, err := processor.CancelMessage(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if msg.Status != model.MessageCancelled {
+		t.Errorf("Expected the message to be cancelled, got %s", msg.Status)
+	}
+
+	if _, err := processor.CancelMessage(context.Background(), 1); !errors.Is(err, ErrMessageNotPending) {
+		t.Errorf("Expected a cancelled message not to be cancelled again, got %v", err)
+	}
+
+	// A failed message waiting for a retry is still pending
+	if msg, err := processor.CancelMessage(context.Background(), 2); err != nil || msg.Status != model.MessageCancelled {
+		t.Errorf("Expected the failed message to be cancelled, got %v", err)
+	}
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"message-sender/model"
+	"message-sender/repository"
+)
+
+// tokenBucket limits the rate of sends from this instance. It holds up to
+// burst tokens and refills rate tokens per second; a rate of 0 is unlimited.
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	if burst <= 0 {
+		burst = int(math.Max(1, math.Ceil(rate)))
+	}
+	return &tokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+	}
+}
+
+// reserve takes a token and returns how long the caller has to wait before
+// using it. When the wait would be longer than maxWait no token is taken and
+// false is returned with the wait.
+func (b *tokenBucket) reserve(now time.Time, maxWait time.Duration) (time.Duration, bool) {
+	if b.rate <= 0 {
+		return 0, true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.last.IsZero() {
+		b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	}
+	b.last = now
+
+	var wait time.Duration
+	if b.tokens < 1 {
+		wait = time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+	}
+	if wait > maxWait {
+		return wait, false
+	}
+
+	b.tokens--
+	return wait, true
+}
+
+// cancel returns a token taken by reserve that was not used.
+func (b *tokenBucket) cancel() {
+	if b.rate <= 0 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tokens = math.Min(b.burst, b.tokens+1)
+}
+
+// UseRecipientLimit caps the sends to every recipient through limits, which
+// is shared by all instances.
+func (s *MessageProcessor) UseRecipientLimit(limits repository.RecipientLimitRepository) {
+	s.recipientLimits = limits
+}
+
+// sendSlot is a reservation made by acquireSendSlot. It is handed back when
+// the message does not go out after all.
+type sendSlot struct {
+	sendID string
+}
+
+// acquireSendSlot reserves a send to msg.Recipient under the frequency cap and
+// waits for the global rate limit. A message held back by either limit is
+// deferred until it may be sent, and false is returned.
+func (s *MessageProcessor) acquireSendSlot(ctx context.Context, msg model.Message) (sendSlot, bool) {
+	recordCtx := context.WithoutCancel(ctx)
+	now := time.Now()
+
+	var slot sendSlot
+	if s.recipientLimits != nil && s.cfg.RateLimit.RecipientLimit > 0 {
+		sendID := fmt.Sprintf("%d:%d", msg.ID, now.UnixNano())
+		wait, err := s.recipientLimits.ReserveRecipientSend(ctx, msg.Recipient, sendID, s.cfg.RateLimit.RecipientLimit, s.recipientWindow())
+		if err != nil {
+			s.logger.Error("Failed to check recipient limit", zap.Error(err), zap.Uint("messageID", msg.ID))
+			s.releaseMessage(recordCtx, msg, "recipient limit check failed")
+			return slot, false
+		}
+		if wait > 0 {
+			s.deferMessage(recordCtx, msg, "recipient frequency cap reached", now.Add(wait))
+			return slot, false
+		}
+		slot.sendID = sendID
+	}
+
+	// Waits longer than half the lease defer the message rather than hold a
+	// claim that could expire before the send.
+	wait, ok := s.rateLimiter.reserve(now, s.leaseDuration()/2)
+	if !ok {
+		s.releaseSendSlot(recordCtx, msg, slot)
+		s.deferMessage(recordCtx, msg, "global rate limit reached", now.Add(wait))
+		return slot, false
+	}
+	if wait == 0 {
+		return slot, true
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return slot, true
+	case <-ctx.Done():
+		s.rateLimiter.cancel()
+		s.releaseSendSlot(recordCtx, msg, slot)
+		s.releaseMessage(recordCtx, msg, "processor draining")
+		return slot, false
+	}
+}
+
+// releaseSendSlot hands back the recipient reservation of a message that was
+// not sent.
+func (s *MessageProcessor) releaseSendSlot(ctx context.Context, msg model.Message, slot sendSlot) {
+	if slot.sendID == "" {
+		return
+	}
+
+	if err := s.recipientLimits.ReleaseRecipientSend(ctx, msg.Recipient, slot.sendID); err != nil {
+		s.logger.Error("Failed to release recipient limit", zap.Error(err), zap.Uint("messageID", msg.ID))
+	}
+}
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zaptest"
+
+	"message-sender/config"
+	"message-sender/model"
+)
+
+type MockRecipientLimitRepository struct {
+	mu    sync.Mutex
+	sends map[string][]string
+}
+
+func (m *MockRecipientLimitRepository) ReserveRecipientSend(ctx context.Context, recipient, sendID string, limit int, window time.Duration) (time.Duration, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.sends == nil {
+		m.sends = make(map[string][]string)
+	}
+	if len(m.sends[recipient]) >= limit {
+		return window, nil
+	}
+	m.sends[recipient] = append(m.sends[recipient], sendID)
+	return 0, nil
+}
+
+func (m *MockRecipientLimitRepository) ReleaseRecipientSend(ctx context.Context, recipient, sendID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	sends := m.sends[recipient]
+	for i, id := range sends {
+		if id == sendID {
+			m.sends[recipient] = append(sends[:i], sends[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func TestTokenBucket_Reserve(t *testing.T) {
+	bucket := newTokenBucket(10, 2)
+	now := time.Now()
+
+	for i := 0; i < 2; i++ {
+		if wait, ok := bucket.reserve(now, time.Second); !ok || wait != 0 {
+			t.Fatalf("Expected burst token %d without waiting, got %s, %v", i, wait, ok)
+		}
+	}
+
+	if wait, ok := bucket.reserve(now, time.Second); !ok || wait != 100*time.Millisecond {
+		t.Errorf("Expected to wait 100ms for the next token, got %s, %v", wait, ok)
+	}
+	if wait, ok := bucket.reserve(now, 150*time.Millisecond); ok || wait != 200*time.Millisecond {
+		t.Errorf("Expected a 200ms wait over maxWait to be refused, got %s, %v", wait, ok)
+	}
+
+	if wait, ok := bucket.reserve(now.Add(time.Second), time.Second); !ok || wait != 0 {
+		t.Errorf("Expected the bucket to refill, got %s, %v", wait, ok)
+	}
+}
+
+func TestTokenBucket_Unlimited(t *testing.T) {
+	bucket := newTokenBucket(0, 0)
+	now := time.Now()
+
+	for i := 0; i < 100; i++ {
+		if wait, ok := bucket.reserve(now, 0); !ok || wait != 0 {
+			t.Fatalf("Expected no limit, got %s, %v", wait, ok)
+		}
+	}
+}
+
+func TestMessageProcessor_ProcessMessages_DefersCappedRecipients(t *testing.T) {
+	calls := 0
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("X-Request-Id", "provider-id")
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer webhook.Close()
+
+	mockRepo := &MockRepository{
+		messages: []model.Message{
+			{ID: 1, Content: "first", Recipient: "+905500000000"},
+			{ID: 2, Content: "second", Recipient: "+905500000000"},
+		},
+	}
+	cfg := &config.Config{
+		Message:   config.MessageConfig{MaxAttempts: 3},
+		Webhook:   config.WebhookConfig{URL: webhook.URL, Timeout: time.Second, FallbackMessageID: true},
+		Cluster:   config.ClusterConfig{InstanceID: "worker-1"},
+		RateLimit: config.RateLimitConfig{RecipientLimit: 1, RecipientWindow: time.Hour},
+	}
+
+	processor := NewMessageProcessor(mockRepo, &MockStatusRepository{}, &MockCacheRepository{}, newTestSenders(t, cfg), zaptest.NewLogger(t), cfg)
+	processor.UseRecipientLimit(&MockRecipientLimitRepository{})
+	processor.processMessages(context.Background(), context.Background())
+
+	expected := []model.MessageStatus{model.MessageSent, model.MessageQueued}
+	if !reflect.DeepEqual(mockRepo.transitions, expected) {
+		t.Fatalf("Expected transitions %v, got %v", expected, mockRepo.transitions)
+	}
+	if calls != 1 {
+		t.Errorf("Expected one webhook call, got %d", calls)
+	}
+	if delay := time.Until(mockRepo.nextAttemptAt); delay < 59*time.Minute || delay > time.Hour {
+		t.Errorf("Expected the capped message to be deferred until the window ends, got %s", delay)
+	}
+}
+
+func TestMessageProcessor_ProcessMessages_DefersOverGlobalRate(t *testing.T) {
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-Id", "provider-id")
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer webhook.Close()
+
+	mockRepo := &MockRepository{
+		messages: []model.Message{
+			{ID: 1, Content: "first", Recipient: "+905500000000"},
+			{ID: 2, Content: "second", Recipient: "+905500000001"},
+		},
+	}
+	cfg := &config.Config{
+		Message:   config.MessageConfig{MaxAttempts: 3},
+		Webhook:   config.WebhookConfig{URL: webhook.URL, Timeout: time.Second, FallbackMessageID: true},
+		Cluster:   config.ClusterConfig{InstanceID: "worker-1", LeaseDuration: time.Second},
+		RateLimit: config.RateLimitConfig{GlobalRate: 0.1, GlobalBurst: 1},
+	}
+
+	processor := NewMessageProcessor(mockRepo, &MockStatusRepository{}, &MockCacheRepository{}, newTestSenders(t, cfg), zaptest.NewLogger(t), cfg)
+	processor.processMessages(context.Background(), context.Background())
+
+	// The second token is 10s away, longer than half the lease
+	expected := []model.MessageStatus{model.MessageSent, model.MessageQueued}
+	if !reflect.DeepEqual(mockRepo.transitions, expected) {
+		t.Fatalf("Expected transitions %v, got %v", expected, mockRepo.transitions)
+	}
+	if delay := time.Until(mockRepo.nextAttemptAt); delay < 9*time.Second || delay > 10*time.Second {
+		t.Errorf("Expected the message to be deferred until the next token, got %s", delay)
+	}
+}
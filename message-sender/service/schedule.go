@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// wakeUp is a timer that can be left unarmed, in which case it never fires.
+type wakeUp struct {
+	timer *time.Timer
+}
+
+func (w *wakeUp) set(at time.Time) {
+	w.stop()
+	if !at.IsZero() {
+		w.timer = time.NewTimer(time.Until(at))
+	}
+}
+
+func (w *wakeUp) stop() {
+	if w.timer != nil {
+		w.timer.Stop()
+		w.timer = nil
+	}
+}
+
+func (w *wakeUp) C() <-chan time.Time {
+	if w.timer == nil {
+		return nil
+	}
+	return w.timer.C
+}
+
+// nextWakeUp returns when the loop should run a tick ahead of nextTick
+// because a scheduled message falls due before it, or the zero time.
+func (s *MessageProcessor) nextWakeUp(ctx context.Context, nextTick time.Time) time.Time {
+	due, err := s.repo.NextScheduledAt(ctx)
+	if err != nil {
+		s.logger.Error("Failed to get next scheduled message", zap.Error(err))
+		return time.Time{}
+	}
+
+	if due.IsZero() || !due.Before(nextTick) {
+		return time.Time{}
+	}
+
+	s.logger.Debug("Waking up early for a scheduled message", zap.Time("dueAt", due))
+	return due
+}
+
+// scheduleChanged tells the local loop to look for the next scheduled message
+// again, after one was created for the future.
+func (s *MessageProcessor) scheduleChanged() {
+	select {
+	case s.rescheduled <- struct{}{}:
+	default:
+	}
+}
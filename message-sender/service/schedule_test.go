@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zaptest"
+
+	"message-sender/config"
+	"message-sender/model"
+)
+
+func waitForTicks(t *testing.T, repo *MockRepository, ticks int) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for repo.tickCount() < ticks && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if got := repo.tickCount(); got != ticks {
+		t.Fatalf("Expected %d ticks, got %d", ticks, got)
+	}
+}
+
+func TestMessageProcessor_WakesUpForScheduledMessages(t *testing.T) {
+	mockRepo := &MockRepository{nextScheduledAt: time.Now().Add(50 * time.Millisecond)}
+	cfg := &config.Config{
+		Message: config.MessageConfig{ProcessInterval: time.Hour},
+	}
+
+	processor := NewMessageProcessor(mockRepo, &MockStatusRepository{}, &MockCacheRepository{}, newTestSenders(t, cfg), zaptest.NewLogger(t), cfg)
+
+	processor.processingMux.Lock()
+	processor.startLoop()
+	processor.processingMux.Unlock()
+	defer processor.shutdownLoop()
+
+	// One tick on start and one when the scheduled message is due
+	waitForTicks(t, mockRepo, 2)
+
+	// A message scheduled through this instance moves the wake-up forward
+	mockRepo.mu.Lock()
+	mockRepo.nextScheduledAt = time.Now().Add(50 * time.Millisecond)
+	mockRepo.mu.Unlock()
+
+	if _, err := processor.CreateMessage(context.Background(), model.CreateMessageRequest{
+		Content:   "reminder",
+		Recipient: "+905500000000",
+		SendAt:    time.Now().Add(50 * time.Millisecond),
+	}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	waitForTicks(t, mockRepo, 3)
+}
+
+func TestMessageProcessor_CreateMessage(t *testing.T) {
+	mockRepo := &MockRepository{}
+	cfg := &config.Config{}
+	processor := NewMessageProcessor(mockRepo, &MockStatusRepository{}, &MockCacheRepository{}, newTestSenders(t, cfg), zaptest.NewLogger(t), cfg)
+
+	sendAt := time.Now().Add(time.Hour).In(time.FixedZone("TRT", 3*60*60))
+	msg, err := processor.CreateMessage(context.Background(), model.CreateMessageRequest{
+		Content:   "reminder",
+		Recipient: "+905500000000",
+		SendAt:    sendAt,
+		Tags:      []string{"reminder"},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if msg.ID == 0 || msg.Status != model.MessageQueued || !msg.SendAt.Equal(sendAt) {
+		t.Errorf("Expected a queued message scheduled for %s, got %+v", sendAt, msg)
+	}
+	// Postgres drops the offset of a TIMESTAMP, so the send time is stored in UTC
+	if mockRepo.saved[0].SendAt.Location() != time.UTC {
+		t.Errorf("Expected the send time in UTC, got %s", mockRepo.saved[0].SendAt)
+	}
+
+}
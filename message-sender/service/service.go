@@ -2,13 +2,44 @@ package service
 
 import (
 	"context"
+	"errors"
+	"io"
 
 	"message-sender/model"
 )
 
+var (
+	ErrMessageNotFound   = errors.New("message not found")
+	ErrInvalidRecipient  = errors.New("recipient must be an E.164 phone number")
+	ErrInvalidMessage    = errors.New("invalid message")
+	ErrInvalidImport     = errors.New("invalid import")
+	ErrImportNotFound    = errors.New("import not found")
+	ErrInvalidCursor     = errors.New("invalid cursor")
+	ErrMessageNotPending = errors.New("message is no longer pending")
+	ErrEmptyUpdate       = errors.New("nothing to update")
+)
+
 type Service interface {
 	StartService(ctx context.Context) error
 	StopService(ctx context.Context) error
 	GetServiceStatus(ctx context.Context) (model.ServiceStatus, error)
+	GetServiceDetails(ctx context.Context) (*model.ServiceStatusResponse, error)
+	GetLeaderInfo() model.LeaderInfo
+	GetBreakers() []model.BreakerInfo
+	ListMessages(ctx context.Context, filter model.MessageFilter, cursor string, limit int, withCount bool) (*model.MessageListResponse, error)
+	ExportMessages(ctx context.Context, filter model.MessageFilter, format model.ExportFormat, w io.Writer) (int, error)
 	GetSentMessages(ctx context.Context, page, limit int) (*model.SentMessagesResponse, error)
+	GetDeadMessages(ctx context.Context, page, limit int) (*model.DeadMessagesResponse, error)
+	RequeueDeadMessage(ctx context.Context, id uint) error
+	RequeueDeadMessages(ctx context.Context, ids []uint) (int64, error)
+	RequeueAllDeadMessages(ctx context.Context) (int64, error)
+	ResolveRoute(recipient string, tags []string) (*model.RouteResolution, error)
+	CreateMessage(ctx context.Context, req model.CreateMessageRequest) (*model.Message, error)
+	UpdateMessage(ctx context.Context, id uint, req model.UpdateMessageRequest) (*model.Message, error)
+	CancelMessage(ctx context.Context, id uint) (*model.Message, error)
+	GetMessage(ctx context.Context, id uint) (*model.MessageDetail, error)
+	GetMessageByExternalID(ctx context.Context, messageID string) (*model.MessageDetail, error)
+	ImportMessages(ctx context.Context, format model.ImportFormat, r io.Reader) (*model.ImportJob, error)
+	GetImportJob(ctx context.Context, id uint) (*model.ImportJob, error)
+	GetImportErrors(ctx context.Context, id uint, page, limit int) (*model.ImportErrorsResponse, error)
 }
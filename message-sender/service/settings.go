@@ -0,0 +1,203 @@
+package service
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	"message-sender/model"
+)
+
+const (
+	defaultMaxAttempts     = 5
+	defaultRetryBaseDelay  = 30 * time.Second
+	defaultRetryMaxDelay   = time.Hour
+	defaultLeaseDuration   = time.Minute
+	defaultDrainTimeout    = 30 * time.Second
+	defaultWorkers         = 1
+	defaultRecipientWindow = time.Hour
+	defaultImportBatchSize = 500
+	defaultImportMaxErrors = 1000
+	defaultExportFetchSize = 1000
+
+	// minLaneQuota is claimed from a lane per tick even when the batch size
+	// leaves nothing for it.
+	minLaneQuota = 1
+)
+
+func (s *MessageProcessor) maxAttempts() int {
+	if s.cfg.Message.MaxAttempts > 0 {
+		return s.cfg.Message.MaxAttempts
+	}
+	return defaultMaxAttempts
+}
+
+// leaseDuration is how long a claimed message stays reserved for this worker
+// before the reaper hands it to another one.
+func (s *MessageProcessor) leaseDuration() time.Duration {
+	if s.cfg.Cluster.LeaseDuration > 0 {
+		return s.cfg.Cluster.LeaseDuration
+	}
+	return defaultLeaseDuration
+}
+
+// ValidateLease checks that a lease outlasts the longest one message can take
+// to send, so that a slow send is never handed to a second worker while it is
+// still in flight. A message can wait up to half the lease for the global rate
+// limit, then for the throttle and timeout of its provider, and of every other
+// provider when it fails over.
+func (s *MessageProcessor) ValidateLease() error {
+	var send time.Duration
+	for _, name := range s.senders.Names() {
+		timeout := s.cfg.Sender.Provider[name].Timeout
+		if timeout <= 0 {
+			return fmt.Errorf("provider %s has no timeout, so a send may outlast any lease", name)
+		}
+
+		attempt := timeout + s.senders.MaxThrottleWait(s.workerCount())
+		if s.cfg.Sender.Failover {
+			send += attempt
+		} else if attempt > send {
+			send = attempt
+		}
+	}
+
+	lease := s.leaseDuration()
+	if s.cfg.RateLimit.GlobalRate > 0 {
+		send += lease / 2
+	}
+
+	if send >= lease {
+		return fmt.Errorf("lease duration %s does not outlast the worst-case send of %s", lease, send)
+	}
+	return nil
+}
+
+// maxContentLen is the longest content a message may have, in characters. It
+// is capped so that valid content always fits the content column.
+func (s *MessageProcessor) maxContentLen() int {
+	if s.cfg.Message.MaxContentLen > 0 && s.cfg.Message.MaxContentLen < maxContentColumnLen {
+		return s.cfg.Message.MaxContentLen
+	}
+	return maxContentColumnLen
+}
+
+func (s *MessageProcessor) workerCount() int {
+	if s.cfg.Message.Workers > 0 {
+		return s.cfg.Message.Workers
+	}
+	return defaultWorkers
+}
+
+func (s *MessageProcessor) drainTimeout() time.Duration {
+	if s.cfg.Message.DrainTimeout > 0 {
+		return s.cfg.Message.DrainTimeout
+	}
+	return defaultDrainTimeout
+}
+
+func (s *MessageProcessor) recipientWindow() time.Duration {
+	if s.cfg.RateLimit.RecipientWindow > 0 {
+		return s.cfg.RateLimit.RecipientWindow
+	}
+	return defaultRecipientWindow
+}
+
+// importBatchSize is how many rows of an import are written per INSERT. It is
+// capped so that a batch stays under the Postgres bind parameter limit.
+func (s *MessageProcessor) importBatchSize() int {
+	size := defaultImportBatchSize
+	if s.cfg.Import.BatchSize > 0 {
+		size = s.cfg.Import.BatchSize
+	}
+	if size > maxImportBatchSize {
+		size = maxImportBatchSize
+	}
+	return size
+}
+
+func (s *MessageProcessor) importMaxErrors() int {
+	if s.cfg.Import.MaxErrors > 0 {
+		return s.cfg.Import.MaxErrors
+	}
+	return defaultImportMaxErrors
+}
+
+func (s *MessageProcessor) exportFetchSize() int {
+	if s.cfg.Export.FetchSize > 0 {
+		return s.cfg.Export.FetchSize
+	}
+	return defaultExportFetchSize
+}
+
+// configuredQuota is the quota set for a lane, or 0 when none is.
+func (s *MessageProcessor) configuredQuota(priority model.MessagePriority) int {
+	switch priority {
+	case model.PriorityHigh:
+		return s.cfg.Priority.HighQuota
+	case model.PriorityNormal:
+		return s.cfg.Priority.NormalQuota
+	case model.PriorityBulk:
+		return s.cfg.Priority.BulkQuota
+	}
+	return 0
+}
+
+// laneQuota is how many messages of the given priority are claimed per tick,
+// before any quota left unused by the lanes above it. Lanes without a quota
+// split what MessageConfig.BatchSize leaves after the quotas that are set, the
+// higher lanes getting any remainder, so a deploy without quotas still claims
+// BatchSize messages per tick in total. Every lane gets at least one message,
+// so that no lane starves however busy the lanes above it are.
+func (s *MessageProcessor) laneQuota(priority model.MessagePriority) int {
+	if quota := s.configuredQuota(priority); quota > 0 {
+		return quota
+	}
+
+	remaining := s.cfg.Message.BatchSize
+	var unset []model.MessagePriority
+	for _, lane := range model.Priorities {
+		if quota := s.configuredQuota(lane); quota > 0 {
+			remaining -= quota
+		} else {
+			unset = append(unset, lane)
+		}
+	}
+	if remaining < len(unset) {
+		return minLaneQuota
+	}
+
+	share := remaining / len(unset)
+	for i, lane := range unset {
+		if lane == priority && i < remaining%len(unset) {
+			return share + 1
+		}
+	}
+	return share
+}
+
+// retryDelay returns the backoff before the next attempt of a message that
+// has failed attempts times. The delay doubles with every attempt, is capped
+// at RetryMaxDelay and jittered between half and the full value so that
+// messages failing together do not retry together.
+func (s *MessageProcessor) retryDelay(attempts int) time.Duration {
+	base := s.cfg.Message.RetryBaseDelay
+	if base <= 0 {
+		base = defaultRetryBaseDelay
+	}
+	maxDelay := s.cfg.Message.RetryMaxDelay
+	if maxDelay <= 0 {
+		maxDelay = defaultRetryMaxDelay
+	}
+
+	delay := base
+	for i := 1; i < attempts && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1)) //nolint:gosec // jitter does not need a secure source
+}
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"message-sender/model"
+)
+
+const (
+	maxTags             = 10
+	maxTagLen           = 32
+	maxMetadataKeys     = 20
+	maxMetadataKeyLen   = 64
+	maxMetadataValueLen = 256
+
+	// maxContentColumnLen is the size of the content column, which caps
+	// MESSAGE_MAX_CONTENT_LEN.
+	maxContentColumnLen = 160
+)
+
+// ValidationError lists every invalid field of a message. It matches
+// ErrInvalidMessage with errors.Is.
+type ValidationError struct {
+	Fields []model.FieldError
+}
+
+func (e *ValidationError) Error() string {
+	parts := make([]string, len(e.Fields))
+	for i, field := range e.Fields {
+		parts[i] = field.Field + ": " + field.Message
+	}
+	return fmt.Sprintf("%s: %s", ErrInvalidMessage, strings.Join(parts, "; "))
+}
+
+func (e *ValidationError) Unwrap() error {
+	return ErrInvalidMessage
+}
+
+func (e *ValidationError) add(field, format string, args ...interface{}) {
+	e.Fields = append(e.Fields, model.FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// validateMessage checks a message before it is queued and fills in the
+// default priority. It returns a *ValidationError listing every invalid field.
+func (s *MessageProcessor) validateMessage(req *model.CreateMessageRequest, now time.Time) error {
+	verr := &ValidationError{}
+
+	if req.Recipient == "" {
+		verr.add("recipient", "is required")
+	} else if !model.IsValidRecipient(req.Recipient) {
+		verr.add("recipient", "must be an E.164 phone number such as +905551111111")
+	}
+
+	if strings.TrimSpace(req.Content) == "" {
+		verr.add("content", "is required")
+	} else if length := utf8.RuneCountInString(req.Content); length > s.maxContentLen() {
+		verr.add("content", "is %d characters long, the limit is %d", length, s.maxContentLen())
+	}
+
+	if req.Priority == "" {
+		req.Priority = model.PriorityNormal
+	}
+	if !req.Priority.IsValid() {
+		verr.add("priority", "must be high, normal or bulk")
+	}
+
+	if !req.ExpiresAt.IsZero() {
+		if !req.ExpiresAt.After(now) {
+			verr.add("expiresAt", "must be in the future")
+		} else if !req.ExpiresAt.After(req.SendAt) {
+			verr.add("expiresAt", "must be after sendAt")
+		}
+	}
+
+	if len(req.Tags) > maxTags {
+		verr.add("tags", "must have at most %d entries", maxTags)
+	}
+	for _, tag := range req.Tags {
+		if tag == "" || utf8.RuneCountInString(tag) > maxTagLen || strings.Contains(tag, ",") {
+			verr.add("tags", "%q must be 1 to %d characters without commas", tag, maxTagLen)
+		}
+	}
+
+	if len(req.Metadata) > maxMetadataKeys {
+		verr.add("metadata", "must have at most %d keys", maxMetadataKeys)
+	}
+	for key, value := range req.Metadata {
+		if key == "" || utf8.RuneCountInString(key) > maxMetadataKeyLen {
+			verr.add("metadata", "key %q must be 1 to %d characters", key, maxMetadataKeyLen)
+		}
+		if utf8.RuneCountInString(value) > maxMetadataValueLen {
+			verr.add("metadata", "value of %q must be at most %d characters", key, maxMetadataValueLen)
+		}
+	}
+
+	if len(verr.Fields) > 0 {
+		return verr
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zaptest"
+
+	"message-sender/config"
+	"message-sender/model"
+)
+
+func TestMessageProcessor_CreateMessage_Validation(t *testing.T) {
+	cfg := &config.Config{
+		Message: config.MessageConfig{MaxContentLen: 10},
+	}
+	processor := NewMessageProcessor(&MockRepository{}, &MockStatusRepository{}, &MockCacheRepository{}, newTestSenders(t, cfg), zaptest.NewLogger(t), cfg)
+
+	tests := []struct {
+		name   string
+		req    model.CreateMessageRequest
+		fields []string
+	}{
+		{
+			name:   "missing fields",
+			req:    model.CreateMessageRequest{},
+			fields: []string{"recipient", "content"},
+		},
+		{
+			name:   "invalid recipient and long content",
+			req:    model.CreateMessageRequest{Recipient: "05551111111", Content: "ten chars!!"},
+			fields: []string{"recipient", "content"},
+		},
+		{
+			name:   "content length counts characters",
+			req:    model.CreateMessageRequest{Recipient: "+905551111111", Content: "şğüçöıŞĞÜÇ"},
+			fields: nil,
+		},
+		{
+			name:   "fifteen digit recipient",
+			req:    model.CreateMessageRequest{Recipient: "+123456789012345", Content: "hi"},
+			fields: nil,
+		},
+		{
+			name:   "unknown priority",
+			req:    model.CreateMessageRequest{Recipient: "+905551111111", Content: "hi", Priority: "urgent"},
+			fields: []string{"priority"},
+		},
+		{
+			name: "expiry before send time",
+			req: model.CreateMessageRequest{
+				Recipient: "+905551111111",
+				Content:   "hi",
+				SendAt:    time.Now().Add(2 * time.Hour),
+				ExpiresAt: time.Now().Add(time.Hour),
+			},
+			fields: []string{"expiresAt"},
+		},
+		{
+			name: "tags and metadata",
+			req: model.CreateMessageRequest{
+				Recipient: "+905551111111",
+				Content:   "hi",
+				Tags:      []string{"otp", "a,b"},
+				Metadata:  map[string]string{"campaign": strings.Repeat("x", maxMetadataValueLen+1)},
+			},
+			fields: []string{"tags", "metadata"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, err := processor.CreateMessage(context.Background(), tt.req)
+
+			if tt.fields == nil {
+				if err != nil {
+					t.Fatalf("Expected no error, got %v", err)
+				}
+				if msg.ID == 0 || msg.Priority != model.PriorityNormal {
+					t.Errorf("Expected a saved message with the normal priority, got %+v", msg)
+				}
+				return
+			}
+
+			var verr *ValidationError
+			if !errors.As(err, &verr) || !errors.Is(err, ErrInvalidMessage) {
+				t.Fatalf("Expected a validation error, got %v", err)
+			}
+
+			var fields []string
+			for _, field := range verr.Fields {
+				fields = append(fields, field.Field)
+			}
+			if !reflect.DeepEqual(fields, tt.fields) {
+				t.Errorf("Expected invalid fields %v, got %v", tt.fields, fields)
+			}
+		})
+	}
+}
+
+func TestMessageProcessor_MaxContentLen_CappedAtColumn(t *testing.T) {
+	cfg := &config.Config{
+		Message: config.MessageConfig{MaxContentLen: 500},
+	}
+	processor := NewMessageProcessor(&MockRepository{}, &MockStatusRepository{}, &MockCacheRepository{}, newTestSenders(t, cfg), zaptest.NewLogger(t), cfg)
+
+	req := model.CreateMessageRequest{Recipient: "+905551111111", Content: strings.Repeat("x", maxContentColumnLen+1)}
+	var verr *ValidationError
+	if _, err := processor.CreateMessage(context.Background(), req); !errors.As(err, &verr) || verr.Fields[0].Field != "content" {
+		t.Errorf("Expected content longer than the column to be rejected, got %v", err)
+	}
+}
@@ -17,16 +17,195 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/api/messages/sent": {
+        "/api/messages": {
+            "post": {
+                "description": "Validate and enqueue a message for sending. A message with sendAt in the future is not sent before that time, and one still unsent at expiresAt expires instead",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "messages"
+                ],
+                "summary": "Create a message",
+                "parameters": [
+                    {
+                        "description": "Message to enqueue",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.CreateMessageRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created message",
+                        "schema": {
+                            "$ref": "#/definitions/#/definitions/model.Message"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request parameters, with every invalid field",
+                        "schema": {
+                            "$ref": "#/definitions/#/definitions/model.ValidationErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/model.StartStopResponse"
+                        }
+                    }
+                }
+            },
             "get": {
-                "description": "Get a paginated list of successfully delivered messages with delivery timestamps",
+                "description": "List messages newest first, filtered by status, recipient, tags and sent or created time ranges. Pages are linked by opaque cursors instead of page numbers, and the total is only counted when asked for.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "messages"
                 ],
-                "summary": "Retrieve delivered messages",
+                "summary": "List messages",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Comma-separated statuses, e.g. sent,delivered",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "E.164 recipient",
+                        "name": "recipient",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated tags the messages must all have",
+                        "name": "tags",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sent at or after this RFC 3339 time",
+                        "name": "sentFrom",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sent before this RFC 3339 time",
+                        "name": "sentTo",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Created at or after this RFC 3339 time",
+                        "name": "createdFrom",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Created before this RFC 3339 time",
+                        "name": "createdTo",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "nextCursor or prevCursor of a previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of messages per page (default: 10, max: 100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Count the messages matching the filters (default: false)",
+                        "name": "count",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Messages",
+                        "schema": {
+                            "$ref": "#/definitions/model.MessageListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid filter or cursor",
+                        "schema": {
+                            "$ref": "#/definitions/model.StartStopResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/model.StartStopResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/messages/by-external-id/{messageId}": {
+            "get": {
+                "description": "Get a message by the ID its provider returned, looked up in the Redis cache of sent messages before the database",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "messages"
+                ],
+                "summary": "Get a message by provider message ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Provider message ID",
+                        "name": "messageId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Message",
+                        "schema": {
+                            "$ref": "#/definitions/model.MessageDetail"
+                        }
+                    },
+                    "404": {
+                        "description": "Message not found",
+                        "schema": {
+                            "$ref": "#/definitions/model.StartStopResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/model.StartStopResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/messages/dead": {
+            "get": {
+                "description": "Get a paginated list of messages that ran out of delivery attempts",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "messages"
+                ],
+                "summary": "Retrieve dead messages",
                 "parameters": [
                     {
                         "type": "integer",
@@ -43,9 +222,9 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "List of delivered messages",
+                        "description": "List of dead messages",
                         "schema": {
-                            "$ref": "#/definitions/model.SentMessagesResponse"
+                            "$ref": "#/definitions/model.DeadMessagesResponse"
                         }
                     },
                     "500": {
@@ -57,9 +236,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/service": {
+        "/api/messages/dead/requeue": {
             "post": {
-                "description": "Start or stop the automated message delivery process",
+                "description": "Put dead messages back in the queue with a fresh attempt budget. Either ids lists the messages or all is set to true to requeue every dead message",
                 "consumes": [
                     "application/json"
                 ],
@@ -67,29 +246,29 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "service"
+                    "messages"
                 ],
-                "summary": "Control message delivery service",
+                "summary": "Requeue dead messages",
                 "parameters": [
                     {
-                        "description": "Service Control Request",
+                        "description": "Messages to requeue",
                         "name": "request",
                         "in": "body",
-                        "required": true,
                         "schema": {
-                            "$ref": "#/definitions/model.StartStopRequest"
-                        }
+                            "$ref": "#/definitions/model.RequeueRequest"
+                        },
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Operation successful",
+                        "description": "Number of requeued messages",
                         "schema": {
-                            "$ref": "#/definitions/model.StartStopResponse"
+                            "$ref": "#/definitions/model.RequeueResponse"
                         }
                     },
                     "400": {
-                        "description": "Invalid request parameters",
+                        "description": "Invalid request, ids and all are both missing or both set",
                         "schema": {
                             "$ref": "#/definitions/model.StartStopResponse"
                         }
@@ -103,65 +282,1238 @@ const docTemplate = `{
                 }
             }
         },
-        "/health": {
-            "get": {
-                "description": "Check the operational status of the message delivery service",
+        "/api/messages/dead/{id}/requeue": {
+            "post": {
+                "description": "Put a single dead message back in the queue with a fresh attempt budget",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "monitoring"
+                    "messages"
+                ],
+                "summary": "Requeue a dead message",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Message ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Service health check",
                 "responses": {
                     "200": {
-                        "description": "Service health information",
+                        "description": "Message requeued",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/model.RequeueResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid message ID",
+                        "schema": {
+                            "$ref": "#/definitions/model.StartStopResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Dead message not found",
+                        "schema": {
+                            "$ref": "#/definitions/model.StartStopResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/model.StartStopResponse"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "model.ActionType": {
-            "type": "string",
-            "enum": [
-                "start",
-                "stop"
-            ],
-            "x-enum-varnames": [
-                "ActionStart",
-                "ActionStop"
-            ]
         },
-        "model.Message": {
-            "type": "object",
-            "properties": {
-                "content": {
-                    "type": "string"
-                },
-                "id": {
-                    "type": "integer"
-                },
-                "isSent": {
-                    "type": "boolean"
-                },
-                "messageId": {
-                    "description": "ID received from webhook response",
-                    "type": "string"
-                },
-                "recipient": {
-                    "type": "string"
-                },
-                "sentAt": {
-                    "type": "string"
+        "/api/messages/export": {
+            "get": {
+                "description": "Stream every message matching the filters, oldest first, as CSV or NDJSON. The format comes from the format parameter or the Accept header (text/csv, application/x-ndjson). Rows are read from a database cursor and written as they arrive, so exports of any size use constant memory. A failure after the first rows were sent closes the connection instead of ending the response.",
+                "produces": [
+                    "text/csv",
+                    "application/x-ndjson"
+                ],
+                "tags": [
+                    "messages"
+                ],
+                "summary": "Export messages",
+                "parameters": [
+                    {
+                        "enum": [
+                            "csv",
+                            "ndjson"
+                        ],
+                        "type": "string",
+                        "description": "Export format, overriding the Accept header (default: csv)",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated statuses (default: sent,delivered)",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "E.164 recipient",
+                        "name": "recipient",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated tags the messages must all have",
+                        "name": "tags",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sent at or after this RFC 3339 time",
+                        "name": "sentFrom",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sent before this RFC 3339 time",
+                        "name": "sentTo",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Created at or after this RFC 3339 time",
+                        "name": "createdFrom",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Created before this RFC 3339 time",
+                        "name": "createdTo",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Exported messages",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid filter",
+                        "schema": {
+                            "$ref": "#/definitions/model.StartStopResponse"
+                        }
+                    },
+                    "406": {
+                        "description": "Unsupported export format",
+                        "schema": {
+                            "$ref": "#/definitions/model.StartStopResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to export messages",
+                        "schema": {
+                            "$ref": "#/definitions/model.StartStopResponse"
+                        }
+                    }
                 }
             }
         },
-        "model.SentMessagesResponse": {
+        "/api/messages/import": {
+            "post": {
+                "description": "Upload messages as CSV or NDJSON. Every row is validated like POST /api/messages, valid rows are written in batches and rejected rows are listed in the import errors. The format comes from the format parameter, the Content-Type header (text/csv, application/x-ndjson) or the content type or extension of a multipart \"file\" part. CSV needs a header row naming the columns recipient, content, priority, sendAt, expiresAt, tags and metadata.<key>.",
+                "consumes": [
+                    "text/csv",
+                    "application/x-ndjson",
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "messages"
+                ],
+                "summary": "Import messages in bulk",
+                "parameters": [
+                    {
+                        "enum": [
+                            "csv",
+                            "ndjson"
+                        ],
+                        "type": "string",
+                        "description": "Upload format, overriding the content type",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "file",
+                        "description": "Upload sent as multipart/form-data",
+                        "name": "file",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Import finished, see its status and row counts",
+                        "schema": {
+                            "$ref": "#/definitions/model.ImportJob"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid upload, such as an unknown CSV column",
+                        "schema": {
+                            "$ref": "#/definitions/model.StartStopResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported import format",
+                        "schema": {
+                            "$ref": "#/definitions/model.StartStopResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/model.StartStopResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/messages/import/{id}": {
+            "get": {
+                "description": "Get the status and row counts of an import",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "messages"
+                ],
+                "summary": "Get an import",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Import ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Import",
+                        "schema": {
+                            "$ref": "#/definitions/model.ImportJob"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid import ID",
+                        "schema": {
+                            "$ref": "#/definitions/model.StartStopResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Import not found",
+                        "schema": {
+                            "$ref": "#/definitions/model.StartStopResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/model.StartStopResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/messages/import/{id}/errors": {
+            "get": {
+                "description": "Get the rows an import rejected, one entry per invalid field, in row order",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "messages"
+                ],
+                "summary": "Get import row errors",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Import ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number for pagination (default: 1)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of errors per page (default: 10, max: 100)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Import row errors",
+                        "schema": {
+                            "$ref": "#/definitions/model.ImportErrorsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid import ID",
+                        "schema": {
+                            "$ref": "#/definitions/model.StartStopResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Import not found",
+                        "schema": {
+                            "$ref": "#/definitions/model.StartStopResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/model.StartStopResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/messages/sent": {
+            "get": {
+                "description": "Get a paginated list of successfully delivered messages with delivery timestamps",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "messages"
+                ],
+                "summary": "Retrieve delivered messages",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Page number for pagination (default: 1)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of messages per page (default: 10, max: 100)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "List of delivered messages",
+                        "schema": {
+                            "$ref": "#/definitions/model.SentMessagesResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/model.StartStopResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/messages/{id}": {
+            "get": {
+                "description": "Get a message with its delivery data and every status change it went through",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "messages"
+                ],
+                "summary": "Get a message",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Message ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Message",
+                        "schema": {
+                            "$ref": "#/definitions/model.MessageDetail"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid message ID",
+                        "schema": {
+                            "$ref": "#/definitions/model.StartStopResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Message not found",
+                        "schema": {
+                            "$ref": "#/definitions/model.StartStopResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/model.StartStopResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Change the content, recipient, sendAt or expiresAt of a message that is still queued or failed and waiting for a retry. Fields left out are kept, and the result is validated like a new message. A message a worker has already claimed can no longer be changed.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "messages"
+                ],
+                "summary": "Edit a pending message",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Message ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Fields to change",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.UpdateMessageRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Updated message",
+                        "schema": {
+                            "$ref": "#/definitions/model.Message"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid message ID, payload or fields",
+                        "schema": {
+                            "$ref": "#/definitions/model.ValidationErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Message not found",
+                        "schema": {
+                            "$ref": "#/definitions/model.StartStopResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Message is no longer pending",
+                        "schema": {
+                            "$ref": "#/definitions/model.StartStopResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/model.StartStopResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Cancel a message that is still queued or failed and waiting for a retry so that it is never sent. A message a worker has already claimed can no longer be cancelled.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "messages"
+                ],
+                "summary": "Cancel a pending message",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Message ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Cancelled message",
+                        "schema": {
+                            "$ref": "#/definitions/model.Message"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid message ID",
+                        "schema": {
+                            "$ref": "#/definitions/model.StartStopResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Message not found",
+                        "schema": {
+                            "$ref": "#/definitions/model.StartStopResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Message is no longer pending",
+                        "schema": {
+                            "$ref": "#/definitions/model.StartStopResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/model.StartStopResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/routing/resolve": {
+            "get": {
+                "description": "Explain which routing rule, provider and sender ID would be used for a recipient. The rule with the longest matching prefix wins, and a rule with tags wins over one without for the same prefix.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "routing"
+                ],
+                "summary": "Resolve the route for a recipient",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Recipient in E.164 format, with the plus encoded as %2B",
+                        "name": "recipient",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated message tags",
+                        "name": "tags",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Matched rule and provider",
+                        "schema": {
+                            "$ref": "#/definitions/model.RouteResolution"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid recipient",
+                        "schema": {
+                            "$ref": "#/definitions/model.StartStopResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/model.StartStopResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/service": {
+            "get": {
+                "description": "Get the cluster-wide service status, this instance's loop and leadership, and the circuit breaker of every provider",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "service"
+                ],
+                "summary": "Get service status",
+                "responses": {
+                    "200": {
+                        "description": "Service status",
+                        "schema": {
+                            "$ref": "#/definitions/model.ServiceStatusResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/model.StartStopResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Start or stop the automated message delivery process",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "service"
+                ],
+                "summary": "Control message delivery service",
+                "parameters": [
+                    {
+                        "description": "Service Control Request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.StartStopRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Operation successful",
+                        "schema": {
+                            "$ref": "#/definitions/model.StartStopResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request parameters",
+                        "schema": {
+                            "$ref": "#/definitions/model.StartStopResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/model.StartStopResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/health": {
+            "get": {
+                "description": "Check the operational status of the message delivery service",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "monitoring"
+                ],
+                "summary": "Service health check",
+                "responses": {
+                    "200": {
+                        "description": "Service health information",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "model.ActionType": {
+            "type": "string",
+            "enum": [
+                "start",
+                "stop"
+            ],
+            "x-enum-varnames": [
+                "ActionStart",
+                "ActionStop"
+            ]
+        },
+        "model.BreakerInfo": {
+            "type": "object",
+            "properties": {
+                "failureRatio": {
+                    "type": "number"
+                },
+                "failures": {
+                    "description": "Unavailable responses in the current window",
+                    "type": "integer"
+                },
+                "openedAt": {
+                    "type": "string"
+                },
+                "provider": {
+                    "type": "string",
+                    "example": "primary"
+                },
+                "requests": {
+                    "description": "Sends in the current window",
+                    "type": "integer"
+                },
+                "retryAt": {
+                    "description": "When the next probe is let through",
+                    "type": "string"
+                },
+                "state": {
+                    "type": "string",
+                    "enum": [
+                        "closed",
+                        "open",
+                        "half-open"
+                    ],
+                    "example": "closed"
+                }
+            }
+        },
+        "model.CreateMessageRequest": {
+            "type": "object",
+            "properties": {
+                "content": {
+                    "description": "Up to MESSAGE_MAX_CONTENT_LEN characters",
+                    "type": "string"
+                },
+                "expiresAt": {
+                    "description": "Defaults to the TTL of the priority or tags",
+                    "type": "string"
+                },
+                "metadata": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    },
+                    "description": "Stored with the message, not sent to the provider. Up to 20 keys of up to 64 characters, values up to 256 characters"
+                },
+                "priority": {
+                    "type": "string",
+                    "enum": [
+                        "high",
+                        "normal",
+                        "bulk"
+                    ],
+                    "example": "normal",
+                    "description": "Defaults to normal"
+                },
+                "recipient": {
+                    "type": "string",
+                    "example": "+905551111111"
+                },
+                "sendAt": {
+                    "description": "Time the message is due, sent right away when empty",
+                    "type": "string"
+                },
+                "tags": {
+                    "description": "Up to 10 tags of up to 32 characters, without commas",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            },
+            "required": [
+                "content",
+                "recipient"
+            ]
+        },
+        "model.DeadMessagesResponse": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "messages": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Message"
+                    }
+                }
+            }
+        },
+        "model.FieldError": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string",
+                    "example": "recipient"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "must be an E.164 phone number such as +905551111111"
+                }
+            }
+        },
+        "model.ImportErrorsResponse": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.ImportRowError"
+                    }
+                }
+            }
+        },
+        "model.ImportFormat": {
+            "type": "string",
+            "enum": [
+                "csv",
+                "ndjson"
+            ],
+            "x-enum-varnames": [
+                "ImportCSV",
+                "ImportNDJSON"
+            ]
+        },
+        "model.ImportJob": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "error": {
+                    "description": "Why a failed import stopped",
+                    "type": "string"
+                },
+                "failedRows": {
+                    "type": "integer"
+                },
+                "finishedAt": {
+                    "type": "string"
+                },
+                "format": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.ImportFormat"
+                        }
+                    ],
+                    "example": "csv"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "importedRows": {
+                    "type": "integer"
+                },
+                "status": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.ImportStatus"
+                        }
+                    ],
+                    "example": "completed"
+                },
+                "totalRows": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.ImportRowError": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string",
+                    "example": "recipient"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "must be an E.164 phone number such as +905551111111"
+                },
+                "row": {
+                    "description": "Data row number, starting at 1 after any header",
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "model.ImportStatus": {
+            "type": "string",
+            "enum": [
+                "running",
+                "completed",
+                "failed"
+            ],
+            "x-enum-varnames": [
+                "ImportRunning",
+                "ImportCompleted",
+                "ImportFailed"
+            ]
+        },
+        "model.LaneBacklog": {
+            "type": "object",
+            "properties": {
+                "due": {
+                    "description": "Messages among them that can be claimed now",
+                    "type": "integer"
+                },
+                "expired": {
+                    "description": "Messages that expired before they were sent",
+                    "type": "integer"
+                },
+                "oldestAt": {
+                    "description": "Creation time of the oldest waiting message",
+                    "type": "string"
+                },
+                "priority": {
+                    "type": "string",
+                    "enum": [
+                        "high",
+                        "normal",
+                        "bulk"
+                    ],
+                    "example": "high"
+                },
+                "queued": {
+                    "description": "Queued or failed messages waiting for an attempt",
+                    "type": "integer"
+                },
+                "quota": {
+                    "description": "Messages claimed from the lane per tick",
+                    "type": "integer"
+                }
+            }
+        },
+        "model.LeaderInfo": {
+            "type": "object",
+            "properties": {
+                "enabled": {
+                    "type": "boolean"
+                },
+                "instanceId": {
+                    "type": "string"
+                },
+                "isLeader": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "model.Message": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "claimedBy": {
+                    "type": "string"
+                },
+                "content": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "expiresAt": {
+                    "description": "Expires instead of being sent after this time when set",
+                    "type": "string"
+                },
+                "failedOverFrom": {
+                    "description": "Providers that were unavailable before the last attempt failed over to provider",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "lastError": {
+                    "type": "string"
+                },
+                "leaseExpiresAt": {
+                    "type": "string"
+                },
+                "messageId": {
+                    "description": "ID received from webhook response",
+                    "type": "string"
+                },
+                "metadata": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "nextAttemptAt": {
+                    "type": "string"
+                },
+                "priority": {
+                    "type": "string",
+                    "enum": [
+                        "high",
+                        "normal",
+                        "bulk"
+                    ],
+                    "example": "normal"
+                },
+                "provider": {
+                    "description": "Provider that handled the last delivery attempt, after any failover",
+                    "type": "string"
+                },
+                "recipient": {
+                    "type": "string"
+                },
+                "sendAt": {
+                    "description": "Not sent before this time when set",
+                    "type": "string"
+                },
+                "senderId": {
+                    "description": "Sender ID chosen by the routing rules for the provider that handled the message",
+                    "type": "string"
+                },
+                "sentAt": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/model.MessageStatus"
+                },
+                "statusUpdatedAt": {
+                    "type": "string"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "model.MessageDetail": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "claimedBy": {
+                    "type": "string"
+                },
+                "content": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "expiresAt": {
+                    "description": "Expires instead of being sent after this time when set",
+                    "type": "string"
+                },
+                "failedOverFrom": {
+                    "description": "Providers that were unavailable before the last attempt failed over to provider",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "history": {
+                    "description": "Every status change of the message, oldest first",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.StatusHistoryEntry"
+                    }
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "lastError": {
+                    "type": "string"
+                },
+                "leaseExpiresAt": {
+                    "type": "string"
+                },
+                "messageId": {
+                    "description": "ID received from webhook response",
+                    "type": "string"
+                },
+                "metadata": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "nextAttemptAt": {
+                    "type": "string"
+                },
+                "priority": {
+                    "type": "string",
+                    "enum": [
+                        "high",
+                        "normal",
+                        "bulk"
+                    ],
+                    "example": "normal"
+                },
+                "provider": {
+                    "description": "Provider that handled the last delivery attempt, after any failover",
+                    "type": "string"
+                },
+                "recipient": {
+                    "type": "string"
+                },
+                "sendAt": {
+                    "description": "Not sent before this time when set",
+                    "type": "string"
+                },
+                "senderId": {
+                    "description": "Sender ID chosen by the routing rules for the provider that handled the message",
+                    "type": "string"
+                },
+                "sentAt": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/model.MessageStatus"
+                },
+                "statusUpdatedAt": {
+                    "type": "string"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "model.MessageListResponse": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "description": "Messages matching the filters, only when requested",
+                    "type": "integer"
+                },
+                "messages": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Message"
+                    }
+                },
+                "nextCursor": {
+                    "description": "Older messages, empty on the last page",
+                    "type": "string"
+                },
+                "prevCursor": {
+                    "description": "Newer messages, empty on the first page",
+                    "type": "string"
+                }
+            }
+        },
+        "model.MessageStatus": {
+            "type": "string",
+            "enum": [
+                "queued",
+                "processing",
+                "sent",
+                "delivered",
+                "failed",
+                "dead",
+                "expired",
+                "cancelled"
+            ],
+            "x-enum-varnames": [
+                "MessageQueued",
+                "MessageProcessing",
+                "MessageSent",
+                "MessageDelivered",
+                "MessageFailed",
+                "MessageDead",
+                "MessageExpired",
+                "MessageCancelled"
+            ]
+        },
+        "model.RequeueRequest": {
+            "type": "object",
+            "properties": {
+                "all": {
+                    "description": "Requeue every dead message instead of ids",
+                    "type": "boolean"
+                },
+                "ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "model.RequeueResponse": {
+            "type": "object",
+            "properties": {
+                "requeued": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.RouteResolution": {
+            "type": "object",
+            "properties": {
+                "matched": {
+                    "type": "boolean"
+                },
+                "provider": {
+                    "type": "string",
+                    "example": "primary"
+                },
+                "reason": {
+                    "type": "string",
+                    "example": "longest matching prefix +90"
+                },
+                "recipient": {
+                    "type": "string",
+                    "example": "+905551111111"
+                },
+                "rule": {
+                    "$ref": "#/definitions/model.RoutingRule"
+                },
+                "senderId": {
+                    "type": "string",
+                    "example": "INSIDER"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "model.RoutingRule": {
+            "type": "object",
+            "properties": {
+                "prefix": {
+                    "type": "string",
+                    "example": "+90"
+                },
+                "provider": {
+                    "type": "string",
+                    "example": "primary"
+                },
+                "senderId": {
+                    "type": "string",
+                    "example": "INSIDER"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "otp"
+                    ]
+                }
+            }
+        },
+        "model.SentMessagesResponse": {
             "type": "object",
             "properties": {
                 "count": {
@@ -175,6 +1527,41 @@ const docTemplate = `{
                 }
             }
         },
+        "model.ServiceStatusResponse": {
+            "type": "object",
+            "properties": {
+                "breakers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.BreakerInfo"
+                    }
+                },
+                "lanes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.LaneBacklog"
+                    }
+                },
+                "leader": {
+                    "$ref": "#/definitions/model.LeaderInfo"
+                },
+                "running": {
+                    "description": "Whether the processing loop of this instance is running",
+                    "type": "boolean"
+                },
+                "status": {
+                    "description": "Cluster-wide status",
+                    "type": "string",
+                    "example": "running"
+                },
+                "throttles": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.ThrottleInfo"
+                    }
+                }
+            }
+        },
         "model.StartStopRequest": {
             "type": "object",
             "properties": {
@@ -202,6 +1589,82 @@ const docTemplate = `{
                     "type": "string"
                 }
             }
+        },
+        "model.StatusHistoryEntry": {
+            "type": "object",
+            "properties": {
+                "changedAt": {
+                    "type": "string"
+                },
+                "fromStatus": {
+                    "description": "Empty when the message was created",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.MessageStatus"
+                        }
+                    ]
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "toStatus": {
+                    "$ref": "#/definitions/model.MessageStatus"
+                }
+            }
+        },
+        "model.ThrottleInfo": {
+            "type": "object",
+            "properties": {
+                "pausedUntil": {
+                    "description": "End of the pause advertised by Retry-After",
+                    "type": "string"
+                },
+                "provider": {
+                    "type": "string",
+                    "example": "primary"
+                },
+                "rate": {
+                    "description": "Current send rate in messages per second, 0 when unlimited",
+                    "type": "number"
+                },
+                "throttled": {
+                    "description": "Whether the send rate is limited after a 429",
+                    "type": "boolean"
+                }
+            }
+        },
+        "model.UpdateMessageRequest": {
+            "type": "object",
+            "properties": {
+                "content": {
+                    "type": "string"
+                },
+                "expiresAt": {
+                    "type": "string"
+                },
+                "recipient": {
+                    "type": "string",
+                    "example": "+905551111111"
+                },
+                "sendAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.ValidationErrorResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string",
+                    "example": "Invalid message"
+                },
+                "fields": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.FieldError"
+                    }
+                }
+            }
         }
     }
 }`
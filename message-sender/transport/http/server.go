@@ -3,9 +3,15 @@ package http
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"mime"
 	"net/http"
+	"net/url"
+	"path"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gorilla/mux"
@@ -18,11 +24,23 @@ import (
 	_ "message-sender/transport/http/docs"
 )
 
+const (
+	// defaultImportTimeout is how long an import upload may take when
+	// IMPORT_TIMEOUT is not set.
+	defaultImportTimeout = 10 * time.Minute
+
+	// defaultExportTimeout is how long an export download may take when
+	// EXPORT_TIMEOUT is not set.
+	defaultExportTimeout = 30 * time.Minute
+)
+
 type Server struct {
-	srv    *http.Server
-	router *mux.Router
-	logger *zap.Logger
-	svc    service.Service
+	srv           *http.Server
+	router        *mux.Router
+	logger        *zap.Logger
+	svc           service.Service
+	importTimeout time.Duration
+	exportTimeout time.Duration
 }
 
 func NewServer(cfg *config.Config, logger *zap.Logger, svc service.Service) *Server {
@@ -35,9 +53,17 @@ func NewServer(cfg *config.Config, logger *zap.Logger, svc service.Service) *Ser
 			ReadTimeout:  cfg.Server.ReadTimeout,
 			WriteTimeout: cfg.Server.WriteTimeout,
 		},
-		router: router,
-		logger: logger,
-		svc:    svc,
+		router:        router,
+		logger:        logger,
+		svc:           svc,
+		importTimeout: cfg.Import.Timeout,
+		exportTimeout: cfg.Export.Timeout,
+	}
+	if server.importTimeout <= 0 {
+		server.importTimeout = defaultImportTimeout
+	}
+	if server.exportTimeout <= 0 {
+		server.exportTimeout = defaultExportTimeout
 	}
 
 	server.registerRoutes()
@@ -61,8 +87,26 @@ func (s *Server) registerRoutes() {
 	api := s.router.PathPrefix("/api").Subrouter()
 
 	api.HandleFunc("/service", s.handleServiceControl).Methods(http.MethodPost)
+	api.HandleFunc("/service", s.handleGetServiceStatus).Methods(http.MethodGet)
 
+	api.HandleFunc("/messages", s.handleCreateMessage).Methods(http.MethodPost)
+	api.HandleFunc("/messages", s.handleListMessages).Methods(http.MethodGet)
 	api.HandleFunc("/messages/sent", s.handleGetSentMessages).Methods(http.MethodGet)
+	api.HandleFunc("/messages/export", s.handleExportMessages).Methods(http.MethodGet)
+	api.HandleFunc("/messages/{id:[0-9]+}", s.handleGetMessage).Methods(http.MethodGet)
+	api.HandleFunc("/messages/{id:[0-9]+}", s.handleUpdateMessage).Methods(http.MethodPatch)
+	api.HandleFunc("/messages/{id:[0-9]+}", s.handleCancelMessage).Methods(http.MethodDelete)
+	api.HandleFunc("/messages/by-external-id/{messageId}", s.handleGetMessageByExternalID).Methods(http.MethodGet)
+
+	api.HandleFunc("/messages/import", s.handleImportMessages).Methods(http.MethodPost)
+	api.HandleFunc("/messages/import/{id:[0-9]+}", s.handleGetImportJob).Methods(http.MethodGet)
+	api.HandleFunc("/messages/import/{id:[0-9]+}/errors", s.handleGetImportErrors).Methods(http.MethodGet)
+
+	api.HandleFunc("/messages/dead", s.handleGetDeadMessages).Methods(http.MethodGet)
+	api.HandleFunc("/messages/dead/requeue", s.handleRequeueDeadMessages).Methods(http.MethodPost)
+	api.HandleFunc("/messages/dead/{id:[0-9]+}/requeue", s.handleRequeueDeadMessage).Methods(http.MethodPost)
+
+	api.HandleFunc("/routing/resolve", s.handleResolveRoute).Methods(http.MethodGet)
 
 	s.router.HandleFunc("/health", s.handleHealthCheck).Methods(http.MethodGet)
 
@@ -110,25 +154,462 @@ func (s *Server) handleServiceControl(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-func (s *Server) handleGetSentMessages(w http.ResponseWriter, r *http.Request) {
-	pageStr := r.URL.Query().Get("page")
-	limitStr := r.URL.Query().Get("limit")
+func (s *Server) handleGetServiceStatus(w http.ResponseWriter, r *http.Request) {
+	response, err := s.svc.GetServiceDetails(r.Context())
+	if err != nil {
+		s.logger.Error("Failed to get service status", zap.Error(err))
+		s.respondWithError(w, http.StatusInternalServerError, "Failed to retrieve service status")
+		return
+	}
 
-	page := 1
-	limit := 10
+	s.respondWithJSON(w, http.StatusOK, response)
+}
 
-	if pageStr != "" {
-		if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
-			page = p
+func (s *Server) handleCreateMessage(w http.ResponseWriter, r *http.Request) {
+	var req model.CreateMessageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	msg, err := s.svc.CreateMessage(r.Context(), req)
+	var verr *service.ValidationError
+	if errors.As(err, &verr) {
+		s.respondWithJSON(w, http.StatusBadRequest, model.ValidationErrorResponse{
+			Error:  "Invalid message",
+			Fields: verr.Fields,
+		})
+		return
+	}
+	if err != nil {
+		s.logger.Error("Failed to create message", zap.Error(err))
+		s.respondWithError(w, http.StatusInternalServerError, "Failed to create message")
+		return
+	}
+
+	s.respondWithJSON(w, http.StatusCreated, msg)
+}
+
+func (s *Server) handleListMessages(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseMessageFilter(r.URL.Query())
+	if err != nil {
+		s.respondWithError(w, http.StatusBadRequest, "Invalid filter: "+err.Error())
+		return
+	}
+
+	query := r.URL.Query()
+	_, limit := parsePagination(r)
+	withCount, _ := strconv.ParseBool(query.Get("count"))
+
+	response, err := s.svc.ListMessages(r.Context(), filter, query.Get("cursor"), limit, withCount)
+	if errors.Is(err, service.ErrInvalidCursor) {
+		s.respondWithError(w, http.StatusBadRequest, "Invalid cursor")
+		return
+	}
+	if err != nil {
+		s.logger.Error("Failed to list messages", zap.Error(err))
+		s.respondWithError(w, http.StatusInternalServerError, "Failed to retrieve messages")
+		return
+	}
+
+	s.respondWithJSON(w, http.StatusOK, response)
+}
+
+func (s *Server) handleExportMessages(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseMessageFilter(r.URL.Query())
+	if err != nil {
+		s.respondWithError(w, http.StatusBadRequest, "Invalid filter: "+err.Error())
+		return
+	}
+
+	format := exportFormat(r)
+	if !format.IsValid() {
+		s.respondWithError(w, http.StatusNotAcceptable, "Unsupported export format, must be csv or ndjson")
+		return
+	}
+
+	// Large exports take longer than the server write timeout allows
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Now().Add(s.exportTimeout)); err != nil {
+		s.logger.Warn("Failed to extend export write deadline", zap.Error(err))
+	}
+
+	contentType := "text/csv; charset=utf-8"
+	if format == model.ExportNDJSON {
+		contentType = "application/x-ndjson"
+	}
+	out := &flushWriter{w: w, rc: rc, start: func() {
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="messages.%s"`, format))
+		w.WriteHeader(http.StatusOK)
+	}}
+
+	exported, err := s.svc.ExportMessages(r.Context(), filter, format, out)
+	if err != nil && !out.started {
+		s.logger.Error("Failed to export messages", zap.Error(err))
+		s.respondWithError(w, http.StatusInternalServerError, "Failed to export messages")
+		return
+	}
+	if err != nil {
+		// The status code is sent once rows are streamed, so the connection
+		// is cut for the client not to take a partial export as complete
+		s.logger.Error("Export aborted", zap.Error(err), zap.Int("exported", exported))
+		panic(http.ErrAbortHandler)
+	}
+
+	// An NDJSON export without messages writes nothing
+	out.begin()
+}
+
+// exportFormat picks the export format from the format query parameter, or
+// else from the Accept header. CSV is the default.
+func exportFormat(r *http.Request) model.ExportFormat {
+	if format := r.URL.Query().Get("format"); format != "" {
+		return model.ExportFormat(format)
+	}
+
+	accept := r.Header.Get("Accept")
+	if accept == "" {
+		return model.ExportCSV
+	}
+	for _, value := range strings.Split(accept, ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(value))
+		if err != nil {
+			continue
+		}
+		switch mediaType {
+		case "text/csv", "text/*", "*/*":
+			return model.ExportCSV
+		case "application/x-ndjson", "application/ndjson":
+			return model.ExportNDJSON
 		}
 	}
+	return ""
+}
 
-	if limitStr != "" {
-		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 100 {
-			limit = l
+// flushWriter sends the response headers with the first write, and every
+// write to the client right away instead of buffering the response.
+type flushWriter struct {
+	w       io.Writer
+	rc      *http.ResponseController
+	start   func()
+	started bool
+}
+
+func (f *flushWriter) begin() {
+	if !f.started {
+		f.started = true
+		f.start()
+	}
+}
+
+func (f *flushWriter) Write(p []byte) (int, error) {
+	f.begin()
+
+	n, err := f.w.Write(p)
+	if err != nil {
+		return n, err
+	}
+	return n, f.rc.Flush()
+}
+
+// parseMessageFilter reads the status, recipient, tags and sent/created time
+// range filters of a message listing.
+func parseMessageFilter(query url.Values) (model.MessageFilter, error) {
+	filter := model.MessageFilter{
+		Recipient: recipientParam(query),
+	}
+
+	if statuses := query.Get("status"); statuses != "" {
+		for _, status := range strings.Split(statuses, ",") {
+			status := model.MessageStatus(strings.TrimSpace(status))
+			if !status.IsValid() {
+				return filter, fmt.Errorf("unknown status %q", status)
+			}
+			filter.Statuses = append(filter.Statuses, status)
+		}
+	}
+
+	if tags := query.Get("tags"); tags != "" {
+		filter.Tags = strings.Split(tags, ",")
+	}
+
+	times := []struct {
+		name string
+		dst  *time.Time
+	}{
+		{"sentFrom", &filter.SentFrom},
+		{"sentTo", &filter.SentTo},
+		{"createdFrom", &filter.CreatedFrom},
+		{"createdTo", &filter.CreatedTo},
+	}
+	for _, param := range times {
+		value := query.Get(param.name)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return filter, fmt.Errorf("%s must be an RFC 3339 time", param.name)
+		}
+		// The timestamp columns have no offset and hold UTC times
+		*param.dst = t.UTC()
+	}
+
+	return filter, nil
+}
+
+// recipientParam returns the recipient query parameter. An unencoded "+" in a
+// query string decodes to a space.
+func recipientParam(query url.Values) string {
+	recipient := query.Get("recipient")
+	if strings.HasPrefix(recipient, " ") {
+		recipient = "+" + recipient[1:]
+	}
+	return recipient
+}
+
+func (s *Server) handleGetMessage(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 32)
+	if err != nil {
+		s.respondWithError(w, http.StatusBadRequest, "Invalid message ID")
+		return
+	}
+
+	detail, err := s.svc.GetMessage(r.Context(), uint(id))
+	if errors.Is(err, service.ErrMessageNotFound) {
+		s.respondWithError(w, http.StatusNotFound, "Message not found")
+		return
+	}
+	if err != nil {
+		s.logger.Error("Failed to get message", zap.Error(err), zap.Uint64("messageID", id))
+		s.respondWithError(w, http.StatusInternalServerError, "Failed to retrieve message")
+		return
+	}
+
+	s.respondWithJSON(w, http.StatusOK, detail)
+}
+
+func (s *Server) handleUpdateMessage(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 32)
+	if err != nil {
+		s.respondWithError(w, http.StatusBadRequest, "Invalid message ID")
+		return
+	}
+
+	var req model.UpdateMessageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	msg, err := s.svc.UpdateMessage(r.Context(), uint(id), req)
+	var verr *service.ValidationError
+	if errors.As(err, &verr) {
+		s.respondWithJSON(w, http.StatusBadRequest, model.ValidationErrorResponse{
+			Error:  "Invalid message",
+			Fields: verr.Fields,
+		})
+		return
+	}
+	if errors.Is(err, service.ErrEmptyUpdate) {
+		s.respondWithError(w, http.StatusBadRequest, "Nothing to update, set content, recipient, sendAt or expiresAt")
+		return
+	}
+	if errors.Is(err, service.ErrMessageNotFound) {
+		s.respondWithError(w, http.StatusNotFound, "Message not found")
+		return
+	}
+	if errors.Is(err, service.ErrMessageNotPending) {
+		s.respondWithError(w, http.StatusConflict, "Message is no longer pending")
+		return
+	}
+	if err != nil {
+		s.logger.Error("Failed to update message", zap.Error(err), zap.Uint64("messageID", id))
+		s.respondWithError(w, http.StatusInternalServerError, "Failed to update message")
+		return
+	}
+
+	s.respondWithJSON(w, http.StatusOK, msg)
+}
+
+func (s *Server) handleCancelMessage(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 32)
+	if err != nil {
+		s.respondWithError(w, http.StatusBadRequest, "Invalid message ID")
+		return
+	}
+
+	msg, err := s.svc.CancelMessage(r.Context(), uint(id))
+	if errors.Is(err, service.ErrMessageNotFound) {
+		s.respondWithError(w, http.StatusNotFound, "Message not found")
+		return
+	}
+	if errors.Is(err, service.ErrMessageNotPending) {
+		s.respondWithError(w, http.StatusConflict, "Message is no longer pending")
+		return
+	}
+	if err != nil {
+		s.logger.Error("Failed to cancel message", zap.Error(err), zap.Uint64("messageID", id))
+		s.respondWithError(w, http.StatusInternalServerError, "Failed to cancel message")
+		return
+	}
+
+	s.respondWithJSON(w, http.StatusOK, msg)
+}
+
+func (s *Server) handleGetMessageByExternalID(w http.ResponseWriter, r *http.Request) {
+	messageID := mux.Vars(r)["messageId"]
+
+	detail, err := s.svc.GetMessageByExternalID(r.Context(), messageID)
+	if errors.Is(err, service.ErrMessageNotFound) {
+		s.respondWithError(w, http.StatusNotFound, "Message not found")
+		return
+	}
+	if err != nil {
+		s.logger.Error("Failed to get message by external ID", zap.Error(err), zap.String("externalID", messageID))
+		s.respondWithError(w, http.StatusInternalServerError, "Failed to retrieve message")
+		return
+	}
+
+	s.respondWithJSON(w, http.StatusOK, detail)
+}
+
+func (s *Server) handleImportMessages(w http.ResponseWriter, r *http.Request) {
+	// Large uploads take longer than the server timeouts allow
+	deadline := time.Now().Add(s.importTimeout)
+	rc := http.NewResponseController(w)
+	if err := rc.SetReadDeadline(deadline); err != nil {
+		s.logger.Warn("Failed to extend import read deadline", zap.Error(err))
+	}
+	if err := rc.SetWriteDeadline(deadline); err != nil {
+		s.logger.Warn("Failed to extend import write deadline", zap.Error(err))
+	}
+
+	body, format, err := importUpload(r)
+	if err != nil {
+		s.respondWithError(w, http.StatusBadRequest, "Invalid import upload")
+		return
+	}
+	if !format.IsValid() {
+		s.respondWithError(w, http.StatusUnsupportedMediaType, "Unsupported import format, must be csv or ndjson")
+		return
+	}
+
+	job, err := s.svc.ImportMessages(r.Context(), format, body)
+	if errors.Is(err, service.ErrInvalidImport) {
+		s.respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		s.logger.Error("Failed to import messages", zap.Error(err))
+		s.respondWithError(w, http.StatusInternalServerError, "Failed to import messages")
+		return
+	}
+
+	s.respondWithJSON(w, http.StatusCreated, job)
+}
+
+// importUpload returns the body of an import and its format. The format comes
+// from the format query parameter, or else from the content type of the
+// request or of its multipart "file" part.
+func importUpload(r *http.Request) (io.Reader, model.ImportFormat, error) {
+	format := model.ImportFormat(r.URL.Query().Get("format"))
+
+	// A missing or malformed content type leaves the format unknown
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "multipart/form-data" {
+		if format == "" {
+			format = importFormat(mediaType, "")
+		}
+		return r.Body, format, nil
+	}
+
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return nil, "", err
+	}
+	for {
+		part, err := reader.NextPart()
+		if err != nil {
+			return nil, "", err
 		}
+		if part.FormName() != "file" {
+			continue
+		}
+
+		if format == "" {
+			partType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+			format = importFormat(partType, part.FileName())
+		}
+		return part, format, nil
+	}
+}
+
+func importFormat(mediaType, fileName string) model.ImportFormat {
+	switch mediaType {
+	case "text/csv":
+		return model.ImportCSV
+	case "application/x-ndjson", "application/ndjson":
+		return model.ImportNDJSON
+	}
+
+	switch strings.ToLower(path.Ext(fileName)) {
+	case ".csv":
+		return model.ImportCSV
+	case ".ndjson", ".jsonl":
+		return model.ImportNDJSON
+	}
+	return ""
+}
+
+func (s *Server) handleGetImportJob(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 32)
+	if err != nil {
+		s.respondWithError(w, http.StatusBadRequest, "Invalid import ID")
+		return
+	}
+
+	job, err := s.svc.GetImportJob(r.Context(), uint(id))
+	if errors.Is(err, service.ErrImportNotFound) {
+		s.respondWithError(w, http.StatusNotFound, "Import not found")
+		return
+	}
+	if err != nil {
+		s.logger.Error("Failed to get import job", zap.Error(err), zap.Uint64("importID", id))
+		s.respondWithError(w, http.StatusInternalServerError, "Failed to retrieve import")
+		return
+	}
+
+	s.respondWithJSON(w, http.StatusOK, job)
+}
+
+func (s *Server) handleGetImportErrors(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 32)
+	if err != nil {
+		s.respondWithError(w, http.StatusBadRequest, "Invalid import ID")
+		return
 	}
 
+	page, limit := parsePagination(r)
+
+	response, err := s.svc.GetImportErrors(r.Context(), uint(id), page, limit)
+	if errors.Is(err, service.ErrImportNotFound) {
+		s.respondWithError(w, http.StatusNotFound, "Import not found")
+		return
+	}
+	if err != nil {
+		s.logger.Error("Failed to get import errors", zap.Error(err), zap.Uint64("importID", id))
+		s.respondWithError(w, http.StatusInternalServerError, "Failed to retrieve import errors")
+		return
+	}
+
+	s.respondWithJSON(w, http.StatusOK, response)
+}
+
+func (s *Server) handleGetSentMessages(w http.ResponseWriter, r *http.Request) {
+	page, limit := parsePagination(r)
+
 	response, err := s.svc.GetSentMessages(r.Context(), page, limit)
 	if err != nil {
 		s.logger.Error("Failed to get sent messages", zap.Error(err))
@@ -139,24 +620,136 @@ func (s *Server) handleGetSentMessages(w http.ResponseWriter, r *http.Request) {
 	s.respondWithJSON(w, http.StatusOK, response)
 }
 
+func (s *Server) handleGetDeadMessages(w http.ResponseWriter, r *http.Request) {
+	page, limit := parsePagination(r)
+
+	response, err := s.svc.GetDeadMessages(r.Context(), page, limit)
+	if err != nil {
+		s.logger.Error("Failed to get dead messages", zap.Error(err))
+		s.respondWithError(w, http.StatusInternalServerError, "Failed to retrieve dead messages")
+		return
+	}
+
+	s.respondWithJSON(w, http.StatusOK, response)
+}
+
+func (s *Server) handleRequeueDeadMessage(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 32)
+	if err != nil {
+		s.respondWithError(w, http.StatusBadRequest, "Invalid message ID")
+		return
+	}
+
+	err = s.svc.RequeueDeadMessage(r.Context(), uint(id))
+	if errors.Is(err, service.ErrMessageNotFound) {
+		s.respondWithError(w, http.StatusNotFound, "Dead message not found")
+		return
+	}
+	if err != nil {
+		s.logger.Error("Failed to requeue dead message", zap.Error(err), zap.Uint64("messageID", id))
+		s.respondWithError(w, http.StatusInternalServerError, "Failed to requeue dead message")
+		return
+	}
+
+	s.respondWithJSON(w, http.StatusOK, model.RequeueResponse{Requeued: 1})
+}
+
+func (s *Server) handleRequeueDeadMessages(w http.ResponseWriter, r *http.Request) {
+	var req model.RequeueRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	// Replaying the whole dead-letter queue has to be asked for explicitly
+	if req.All == (len(req.IDs) > 0) {
+		s.respondWithError(w, http.StatusBadRequest, `Set either "ids" to the messages to requeue or "all" to true`)
+		return
+	}
+
+	var requeued int64
+	var err error
+	if req.All {
+		requeued, err = s.svc.RequeueAllDeadMessages(r.Context())
+	} else {
+		requeued, err = s.svc.RequeueDeadMessages(r.Context(), req.IDs)
+	}
+	if err != nil {
+		s.logger.Error("Failed to requeue dead messages", zap.Error(err))
+		s.respondWithError(w, http.StatusInternalServerError, "Failed to requeue dead messages")
+		return
+	}
+
+	s.respondWithJSON(w, http.StatusOK, model.RequeueResponse{Requeued: requeued})
+}
+
+func (s *Server) handleResolveRoute(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	recipient := recipientParam(query)
+
+	var tags []string
+	if tagsStr := query.Get("tags"); tagsStr != "" {
+		tags = strings.Split(tagsStr, ",")
+	}
+
+	resolution, err := s.svc.ResolveRoute(recipient, tags)
+	if errors.Is(err, service.ErrInvalidRecipient) {
+		s.respondWithError(w, http.StatusBadRequest, "Invalid recipient, must be an E.164 phone number")
+		return
+	}
+	if err != nil {
+		s.logger.Error("Failed to resolve route", zap.Error(err))
+		s.respondWithError(w, http.StatusInternalServerError, "Failed to resolve route")
+		return
+	}
+
+	s.respondWithJSON(w, http.StatusOK, resolution)
+}
+
 func (s *Server) handleHealthCheck(w http.ResponseWriter, r *http.Request) {
 	status, err := s.svc.GetServiceStatus(r.Context())
 	if err != nil {
 		s.respondWithJSON(w, http.StatusOK, map[string]interface{}{
-			"status":  "ok",
-			"service": "unknown",
-			"time":    time.Now().Format(time.RFC3339),
+			"status":   "ok",
+			"service":  "unknown",
+			"leader":   s.svc.GetLeaderInfo(),
+			"breakers": s.svc.GetBreakers(),
+			"time":     time.Now().Format(time.RFC3339),
 		})
 		return
 	}
 
 	s.respondWithJSON(w, http.StatusOK, map[string]interface{}{
-		"status":  "ok",
-		"service": status,
-		"time":    time.Now().Format(time.RFC3339),
+		"status":   "ok",
+		"service":  status,
+		"leader":   s.svc.GetLeaderInfo(),
+		"breakers": s.svc.GetBreakers(),
+		"time":     time.Now().Format(time.RFC3339),
 	})
 }
 
+func parsePagination(r *http.Request) (int, int) {
+	pageStr := r.URL.Query().Get("page")
+	limitStr := r.URL.Query().Get("limit")
+
+	page := 1
+	limit := 10
+
+	if pageStr != "" {
+		if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
+			page = p
+		}
+	}
+
+	if limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 100 {
+			limit = l
+		}
+	}
+
+	return page, limit
+}
+
 func (s *Server) respondWithError(w http.ResponseWriter, code int, message string) {
 	s.respondWithJSON(w, code, map[string]string{"error": message})
 }
@@ -0,0 +1,90 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zaptest"
+
+	"message-sender/config"
+	"message-sender/service"
+)
+
+// MockService records the requeue calls. Every other method is left to the
+// embedded nil interface and panics when called.
+type MockService struct {
+	service.Service
+	requeuedIDs []uint
+	requeuedAll bool
+}
+
+func (m *MockService) RequeueDeadMessages(ctx context.Context, ids []uint) (int64, error) {
+	m.requeuedIDs = ids
+	return int64(len(ids)), nil
+}
+
+func (m *MockService) RequeueAllDeadMessages(ctx context.Context) (int64, error) {
+	m.requeuedAll = true
+	return 3, nil
+}
+
+func TestServer_RequeueDeadMessages(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		status      int
+		expectedIDs []uint
+		all         bool
+	}{
+		{name: "ids", body: `{"ids":[1,2]}`, status: http.StatusOK, expectedIDs: []uint{1, 2}},
+		{name: "all", body: `{"all":true}`, status: http.StatusOK, all: true},
+		{name: "empty body", body: ``, status: http.StatusBadRequest},
+		{name: "empty object", body: `{}`, status: http.StatusBadRequest},
+		{name: "empty ids", body: `{"ids":[]}`, status: http.StatusBadRequest},
+		{name: "ids and all", body: `{"ids":[1],"all":true}`, status: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &MockService{}
+			server := NewServer(&config.Config{}, zaptest.NewLogger(t), svc)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/messages/dead/requeue", strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+			server.router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("Expected status %d, got %d: %s", tt.status, rec.Code, rec.Body.String())
+			}
+			if !reflect.DeepEqual(svc.requeuedIDs, tt.expectedIDs) || svc.requeuedAll != tt.all {
+				t.Errorf("Expected ids %v and all %v to be requeued, got %v and %v", tt.expectedIDs, tt.all, svc.requeuedIDs, svc.requeuedAll)
+			}
+		})
+	}
+}
+
+func TestParseMessageFilter_ConvertsTimesToUTC(t *testing.T) {
+	query := url.Values{
+		"sentFrom":  {"2024-01-02T15:00:00+03:00"},
+		"createdTo": {"2024-01-02T15:00:00Z"},
+	}
+
+	filter, err := parseMessageFilter(query)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC)
+	if filter.SentFrom != expected {
+		t.Errorf("Expected sentFrom %s, got %s", expected, filter.SentFrom)
+	}
+	if filter.CreatedTo.Location() != time.UTC {
+		t.Errorf("Expected createdTo in UTC, got %s", filter.CreatedTo)
+	}
+}